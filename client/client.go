@@ -0,0 +1,201 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/TomChv/jsonrpc2/common"
+	"github.com/TomChv/jsonrpc2/redact"
+	"github.com/TomChv/jsonrpc2/trace"
+)
+
+var (
+	ErrEmptyResponse    = errors.New("server sent no response")
+	ErrUnexpectedStatus = errors.New("unexpected http status")
+)
+
+// Client calls the methods of a JSON RPC 2.0 server over HTTP
+type Client struct {
+	url    string
+	http   *http.Client
+	header http.Header
+
+	tracer   trace.Tracer
+	redactor *redact.Redactor
+
+	// id is the identifier of the last request
+	id int64
+}
+
+// response is a Response whose result is decoded on demand
+type response struct {
+	Result json.RawMessage  `json:"result"`
+	Error  *common.RpcError `json:"error"`
+}
+
+// New create a Client of the server listening on url
+func New(url string) *Client {
+	return &Client{
+		url:      url,
+		http:     http.DefaultClient,
+		header:   make(http.Header),
+		redactor: redact.New(),
+	}
+}
+
+// SetHTTPClient set the HTTP client used to send requests, default is
+// http.DefaultClient
+func (c *Client) SetHTTPClient(h *http.Client) *Client {
+	c.http = h
+	return c
+}
+
+// SetHeader set a header sent with every request
+func (c *Client) SetHeader(key, value string) *Client {
+	c.header.Set(key, value)
+	return c
+}
+
+// SetTracer open a span for each call with tracer, the span context is sent
+// to the server.
+// Params and results are redacted, see AddRedaction.
+func (c *Client) SetTracer(tracer trace.Tracer) *Client {
+	c.tracer = tracer
+	return c
+}
+
+// AddRedaction hide the values selected by paths from the spans of the calls
+// of the methods matching pattern.
+// Paths are JSON paths rooted at params or result, see redact.Path.
+//
+// Fields of params and results tagged `rpc:"secret"` are always redacted.
+func (c *Client) AddRedaction(pattern string, paths ...string) error {
+	return c.redactor.Add(pattern, paths...)
+}
+
+// Call method with params and decode its result into result.
+// If the server returns an error, it is returned as a *common.RpcError.
+//
+// If ctx has a deadline, the remaining time is sent to the server in
+// common.HeaderTimeout. If ctx holds a trace context, it is sent in the
+// traceparent header.
+func (c *Client) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	id := atomic.AddInt64(&c.id, 1)
+
+	ctx, span := c.startSpan(ctx, method, params)
+	defer span.End()
+	span.SetAttribute(trace.AttributeID, strconv.FormatInt(id, 10))
+
+	body, err := NewRequest().SetID(id).SetMethod(method).SetParams(params).Bytes()
+	if err != nil {
+		return err
+	}
+
+	data, err := c.Send(ctx, body)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return ErrEmptyResponse
+	}
+
+	var res response
+	if err := json.Unmarshal(data, &res); err != nil {
+		return err
+	}
+
+	if res.Error != nil {
+		span.SetAttribute(trace.AttributeErrorCode, res.Error.Code)
+		return res.Error
+	}
+
+	if c.tracer != nil {
+		paths := append(c.redactor.Paths(method), redact.TypePaths(redact.RootResult, reflect.TypeOf(result))...)
+		span.SetAttribute(trace.AttributeResult, string(redact.Apply(redact.RootResult, res.Result, paths)))
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(res.Result, result)
+}
+
+// Notify call method with params without waiting for a result
+func (c *Client) Notify(ctx context.Context, method string, params interface{}) error {
+	ctx, span := c.startSpan(ctx, method, params)
+	defer span.End()
+
+	body, err := NewRequest().SetMethod(method).SetParams(params).Bytes()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Send(ctx, body)
+	return err
+}
+
+// startSpan open the span of a call if the client has a tracer
+func (c *Client) startSpan(ctx context.Context, method string, params interface{}) (context.Context, trace.Span) {
+	if c.tracer == nil {
+		return ctx, trace.NoopSpan{}
+	}
+
+	ctx, span := c.tracer.Start(ctx, "jsonrpc.client.call")
+	span.SetAttribute(trace.AttributeMethod, method)
+	if params != nil {
+		paths := append(c.redactor.Paths(method), redact.ValuePaths(redact.RootParams, params)...)
+		span.SetAttribute(trace.AttributeParams, string(redact.Value(redact.RootParams, params, paths)))
+	}
+
+	return ctx, span
+}
+
+// Send post a raw request or batch to the server and return the raw response
+// body, it is empty for notifications.
+// Headers, trace context and deadline are sent as for Call.
+func (c *Client) Send(ctx context.Context, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	trace.Inject(ctx, req.Header)
+
+	if deadline, ok := ctx.Deadline(); ok {
+		req.Header.Set(common.HeaderTimeout, common.FormatTimeout(time.Until(deadline)))
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Errors are sent with an error status when the server follows the
+	// JSON RPC over HTTP conventions
+	if len(data) == 0 && res.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("%w: %s", ErrUnexpectedStatus, res.Status)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,180 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/TomChv/jsonrpc2/common"
+	"github.com/TomChv/jsonrpc2/redact"
+	"github.com/TomChv/jsonrpc2/server"
+	"github.com/TomChv/jsonrpc2/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockService struct {
+	notified chan int
+}
+
+func (ms *mockService) Sum(a, b int) int {
+	return a + b
+}
+
+func (ms *mockService) Fail() error {
+	return errors.New("failure")
+}
+
+func (ms *mockService) Notify(n int) error {
+	ms.notified <- n
+	return nil
+}
+
+func (ms *mockService) Timeout(ctx context.Context) string {
+	return server.MetadataFromContext(ctx).Get(common.HeaderTimeout)
+}
+
+func (ms *mockService) Header(ctx context.Context) string {
+	return server.MetadataFromContext(ctx).Get("X-Api-Key")
+}
+
+func newTestServer(t *testing.T) (*Client, *mockService) {
+	service := &mockService{notified: make(chan int, 1)}
+
+	s := server.New(context.TODO())
+	assert.Nil(t, s.Register("mock", service))
+
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+
+	return New(ts.URL + "/"), service
+}
+
+func TestClient_Call(t *testing.T) {
+	c, _ := newTestServer(t)
+
+	var sum int
+	assert.Nil(t, c.Call(context.TODO(), "mock_sum", []int{1, 2}, &sum))
+	assert.Equal(t, 3, sum)
+
+	assert.Nil(t, c.Call(context.TODO(), "mock_sum", []int{1, 2}, nil))
+
+	err := c.Call(context.TODO(), "mock_fail", nil, nil)
+	var rpcErr *common.RpcError
+	assert.True(t, errors.As(err, &rpcErr))
+	assert.Equal(t, int64(-32603), rpcErr.Code)
+	assert.Equal(t, "failure", rpcErr.Data)
+}
+
+func TestClient_Notify(t *testing.T) {
+	c, service := newTestServer(t)
+
+	assert.Nil(t, c.Notify(context.TODO(), "mock_notify", []int{4}))
+	assert.Equal(t, 4, <-service.notified)
+}
+
+func TestClient_Send(t *testing.T) {
+	c, _ := newTestServer(t)
+
+	res, err := c.Send(context.TODO(), []byte(`[{"jsonrpc": "2.0", "method": "mock_sum", "params": [1, 2], "id": 1}]`))
+	assert.Nil(t, err)
+	assert.JSONEq(t, `[{"jsonrpc": "2.0", "result": 3, "id": 1}]`, string(res))
+
+	res, err = c.Send(context.TODO(), []byte(`{"jsonrpc": "2.0", "method": "mock_notify", "params": [1]}`))
+	assert.Nil(t, err)
+	assert.Empty(t, res)
+}
+
+func TestClient_SetHeader(t *testing.T) {
+	c, _ := newTestServer(t)
+	c.SetHeader("X-Api-Key", "secret")
+
+	var key string
+	assert.Nil(t, c.Call(context.TODO(), "mock_header", nil, &key))
+	assert.Equal(t, "secret", key)
+}
+
+func TestClient_Deadline(t *testing.T) {
+	c, _ := newTestServer(t)
+
+	var timeout string
+	assert.Nil(t, c.Call(context.TODO(), "mock_timeout", nil, &timeout))
+	assert.Equal(t, "", timeout)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Minute)
+	defer cancel()
+
+	assert.Nil(t, c.Call(ctx, "mock_timeout", nil, &timeout))
+	ms, err := strconv.Atoi(timeout)
+	assert.Nil(t, err)
+	assert.InDelta(t, time.Minute.Milliseconds(), ms, 1000)
+}
+
+func TestClient_UnexpectedStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	err := New(ts.URL).Call(context.TODO(), "mock_sum", []int{1, 2}, nil)
+	assert.ErrorIs(t, err, ErrUnexpectedStatus)
+
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	err = New(ts.URL).Call(context.TODO(), "mock_sum", []int{1, 2}, nil)
+	assert.Equal(t, ErrEmptyResponse, err)
+}
+
+func TestClient_Traceparent(t *testing.T) {
+	var received string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(trace.Header)
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","result":null,"id":1}`))
+	}))
+	defer ts.Close()
+
+	c := New(ts.URL)
+	assert.Nil(t, c.Call(context.TODO(), "mock_sum", nil, nil))
+	assert.Equal(t, "", received)
+
+	sc := trace.NewSpanContext(trace.SpanContext{})
+	assert.Nil(t, c.Call(trace.ContextWithSpanContext(context.TODO(), sc), "mock_sum", nil, nil))
+	assert.Equal(t, sc.Traceparent(), received)
+}
+
+type mockToken struct {
+	Value string `json:"value" rpc:"secret"`
+}
+
+func TestClient_Tracer(t *testing.T) {
+	var received string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(trace.Header)
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","result":{"value":"foo","session":"bar"},"id":1}`))
+	}))
+	defer ts.Close()
+
+	recorder := trace.NewRecorder()
+	c := New(ts.URL).SetTracer(recorder)
+	assert.Equal(t, redact.ErrInvalidPath, c.AddRedaction("auth_*", "session"))
+	assert.Nil(t, c.AddRedaction("auth_*", "result.session", "params[0]"))
+
+	var result mockToken
+	assert.Nil(t, c.Call(context.TODO(), "auth_login", []interface{}{"bob", mockToken{Value: "foo"}}, &result))
+	assert.Equal(t, "foo", result.Value)
+
+	spans := recorder.Spans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "jsonrpc.client.call", spans[0].Name)
+	assert.Equal(t, spans[0].SpanContext.Traceparent(), received)
+	assert.Equal(t, map[string]interface{}{
+		trace.AttributeMethod: "auth_login",
+		trace.AttributeID:     "1",
+		trace.AttributeParams: `["[REDACTED]",{"value":"[REDACTED]"}]`,
+		trace.AttributeResult: `{"session":"[REDACTED]","value":"[REDACTED]"}`,
+	}, spans[0].Attributes)
+}
@@ -3,3 +3,15 @@ package common
 // RequestID is an identifier established by the Client that must contain a
 // String, a Number or NULL if included
 type RequestID = interface{}
+
+// nullID is the type of NullID
+type nullID struct{}
+
+func (nullID) MarshalJSON() ([]byte, error) {
+	return []byte("null"), nil
+}
+
+// NullID is the identifier of a request that includes a null id.
+// Unlike a request without id, such a request is not a notification and its
+// response contains a null id.
+var NullID RequestID = nullID{}
@@ -0,0 +1,32 @@
+package common
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+var ErrInvalidTimeout = errors.New("invalid timeout")
+
+// HeaderTimeout is the header, or metadata key, in which a client sends the
+// time it is willing to wait for a response
+const HeaderTimeout = "X-Rpc-Timeout"
+
+// FormatTimeout format d as the value of HeaderTimeout, a number of
+// milliseconds
+func FormatTimeout(d time.Duration) string {
+	ms := d.Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+	return strconv.FormatInt(ms, 10)
+}
+
+// ParseTimeout parse a value of HeaderTimeout
+func ParseTimeout(value string) (time.Duration, error) {
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || ms <= 0 {
+		return 0, ErrInvalidTimeout
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
@@ -0,0 +1,288 @@
+// Package conformance is a JSON-RPC 2.0 conformance test suite for HTTP
+// servers.
+//
+// It is not tied to this implementation, any http.Handler serving JSON-RPC
+// 2.0 over POST requests can be tested with Run.
+// The handler must expose the methods used in the examples of the
+// specification :
+//   - subtract : with positional params [minuend, subtrahend] or named params
+//     {"minuend", "subtrahend"}, returns minuend - subtrahend
+//   - sum : returns the sum of its positional params
+//   - update : accepts any params, used as notification
+//   - notify_hello : accepts any params, used as notification
+//   - get_data : returns ["hello", 5]
+//
+// Methods foobar and foo.get must not exist.
+//
+// See https://www.jsonrpc.org/specification for more information
+package conformance
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// Case is a request sent to the server along with its expected response.
+//
+// Responses are compared on their jsonrpc, result, error code and id
+// members, error messages and data are implementation-defined.
+// Batch responses may be in any order.
+type Case struct {
+	Name string
+
+	// Request is the raw body sent to the server
+	Request string
+
+	// Response is the expected raw response, empty if the server must not
+	// send anything
+	Response string
+}
+
+// Cases are the examples of the specification followed by cases covering
+// each MUST of the specification
+var Cases = []Case{
+	{
+		Name:     "rpc call with positional parameters",
+		Request:  `{"jsonrpc": "2.0", "method": "subtract", "params": [42, 23], "id": 1}`,
+		Response: `{"jsonrpc": "2.0", "result": 19, "id": 1}`,
+	},
+	{
+		Name:     "rpc call with positional parameters - reverse",
+		Request:  `{"jsonrpc": "2.0", "method": "subtract", "params": [23, 42], "id": 2}`,
+		Response: `{"jsonrpc": "2.0", "result": -19, "id": 2}`,
+	},
+	{
+		Name:     "rpc call with named parameters",
+		Request:  `{"jsonrpc": "2.0", "method": "subtract", "params": {"subtrahend": 23, "minuend": 42}, "id": 3}`,
+		Response: `{"jsonrpc": "2.0", "result": 19, "id": 3}`,
+	},
+	{
+		Name:     "rpc call with named parameters - reverse",
+		Request:  `{"jsonrpc": "2.0", "method": "subtract", "params": {"minuend": 42, "subtrahend": 23}, "id": 4}`,
+		Response: `{"jsonrpc": "2.0", "result": 19, "id": 4}`,
+	},
+	{
+		Name:    "a Notification",
+		Request: `{"jsonrpc": "2.0", "method": "update", "params": [1,2,3,4,5]}`,
+	},
+	{
+		Name:    "a Notification without params",
+		Request: `{"jsonrpc": "2.0", "method": "foobar"}`,
+	},
+	{
+		Name:     "rpc call of non-existent method",
+		Request:  `{"jsonrpc": "2.0", "method": "foobar", "id": "1"}`,
+		Response: `{"jsonrpc": "2.0", "error": {"code": -32601, "message": "Method not found"}, "id": "1"}`,
+	},
+	{
+		Name:     "rpc call with invalid JSON",
+		Request:  `{"jsonrpc": "2.0", "method": "foobar, "params": "bar", "baz]`,
+		Response: `{"jsonrpc": "2.0", "error": {"code": -32700, "message": "Parse error"}, "id": null}`,
+	},
+	{
+		Name:     "rpc call with invalid Request object",
+		Request:  `{"jsonrpc": "2.0", "method": 1, "params": "bar"}`,
+		Response: `{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": null}`,
+	},
+	{
+		Name: "rpc call Batch, invalid JSON",
+		Request: `[
+  {"jsonrpc": "2.0", "method": "sum", "params": [1,2,4], "id": "1"},
+  {"jsonrpc": "2.0", "method"
+]`,
+		Response: `{"jsonrpc": "2.0", "error": {"code": -32700, "message": "Parse error"}, "id": null}`,
+	},
+	{
+		Name:     "rpc call with an empty Array",
+		Request:  `[]`,
+		Response: `{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": null}`,
+	},
+	{
+		Name:     "rpc call with an invalid Batch (but not empty)",
+		Request:  `[1]`,
+		Response: `[{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": null}]`,
+	},
+	{
+		Name:    "rpc call with invalid Batch",
+		Request: `[1,2,3]`,
+		Response: `[
+  {"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": null},
+  {"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": null},
+  {"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": null}
+]`,
+	},
+	{
+		Name: "rpc call Batch",
+		Request: `[
+  {"jsonrpc": "2.0", "method": "sum", "params": [1,2,4], "id": "1"},
+  {"jsonrpc": "2.0", "method": "notify_hello", "params": [7]},
+  {"jsonrpc": "2.0", "method": "subtract", "params": [42,23], "id": "2"},
+  {"foo": "boo"},
+  {"jsonrpc": "2.0", "method": "foo.get", "params": {"name": "myself"}, "id": "5"},
+  {"jsonrpc": "2.0", "method": "get_data", "id": "9"}
+]`,
+		Response: `[
+  {"jsonrpc": "2.0", "result": 7, "id": "1"},
+  {"jsonrpc": "2.0", "result": 19, "id": "2"},
+  {"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": null},
+  {"jsonrpc": "2.0", "error": {"code": -32601, "message": "Method not found"}, "id": "5"},
+  {"jsonrpc": "2.0", "result": ["hello", 5], "id": "9"}
+]`,
+	},
+	{
+		Name: "rpc call Batch (all notifications)",
+		Request: `[
+  {"jsonrpc": "2.0", "method": "notify_sum", "params": [1,2,4]},
+  {"jsonrpc": "2.0", "method": "notify_hello", "params": [7]}
+]`,
+	},
+	{
+		Name:     "jsonrpc member is required",
+		Request:  `{"method": "sum", "params": [1, 2], "id": 1}`,
+		Response: `{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": 1}`,
+	},
+	{
+		Name:     "jsonrpc member must be exactly 2.0",
+		Request:  `{"jsonrpc": "1.0", "method": "sum", "params": [1, 2], "id": 1}`,
+		Response: `{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": 1}`,
+	},
+	{
+		Name:     "params must be a structured value",
+		Request:  `{"jsonrpc": "2.0", "method": "sum", "params": 3, "id": 1}`,
+		Response: `{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": 1}`,
+	},
+	{
+		Name:     "unknown members are rejected",
+		Request:  `{"jsonrpc": "2.0", "method": "sum", "params": [1, 2], "id": 1, "foo": "bar"}`,
+		Response: `{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": 1}`,
+	},
+	{
+		Name:     "members are case-sensitive",
+		Request:  `{"jsonrpc": "2.0", "Method": "sum", "params": [1, 2], "id": 1}`,
+		Response: `{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": 1}`,
+	},
+	{
+		Name:     "id must be a String, a Number or NULL",
+		Request:  `{"jsonrpc": "2.0", "method": "sum", "params": [1, 2], "id": {"foo": "bar"}}`,
+		Response: `{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": null}`,
+	},
+	{
+		Name:     "a null id is not a Notification",
+		Request:  `{"jsonrpc": "2.0", "method": "sum", "params": [1, 2], "id": null}`,
+		Response: `{"jsonrpc": "2.0", "result": 3, "id": null}`,
+	},
+	{
+		Name:     "rpc. methods are reserved",
+		Request:  `{"jsonrpc": "2.0", "method": "rpc.foo", "id": 1}`,
+		Response: `{"jsonrpc": "2.0", "error": {"code": -32601, "message": "Method not found"}, "id": 1}`,
+	},
+}
+
+// Run sends each of Cases to h and reports responses that do not match the
+// expected ones
+func Run(t *testing.T, h http.Handler) {
+	t.Helper()
+
+	for _, c := range Cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			if err := Check(h, c); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// Check sends the case request to h and returns an error if its response
+// does not match the expected one
+func Check(h http.Handler, c Case) error {
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(c.Request)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if c.Response == "" {
+		if len(bytes.TrimSpace(body)) != 0 {
+			return fmt.Errorf("expected no response, got %s", body)
+		}
+		return nil
+	}
+
+	var expected, actual interface{}
+	if err := json.Unmarshal([]byte(c.Response), &expected); err != nil {
+		return fmt.Errorf("invalid expected response: %w", err)
+	}
+	if err := json.Unmarshal(body, &actual); err != nil {
+		return fmt.Errorf("response is not valid JSON: %s", body)
+	}
+
+	if !match(expected, actual) {
+		return fmt.Errorf("expected %s, got %s", c.Response, body)
+	}
+	return nil
+}
+
+// match compare two decoded responses or batch of responses
+func match(expected, actual interface{}) bool {
+	expectedBatch, ok := expected.([]interface{})
+	if !ok {
+		return reflect.DeepEqual(normalize(expected), normalize(actual))
+	}
+
+	actualBatch, ok := actual.([]interface{})
+	if !ok || len(actualBatch) != len(expectedBatch) {
+		return false
+	}
+
+	// Responses of a batch may be in any order
+	used := make([]bool, len(actualBatch))
+	for _, e := range expectedBatch {
+		found := false
+		for i, a := range actualBatch {
+			if !used[i] && reflect.DeepEqual(normalize(e), normalize(a)) {
+				used[i], found = true, true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// normalize drop the implementation-defined members of a response
+func normalize(res interface{}) interface{} {
+	obj, ok := res.(map[string]interface{})
+	if !ok {
+		return res
+	}
+
+	normalized := make(map[string]interface{}, len(obj))
+	for k, v := range obj {
+		normalized[k] = v
+	}
+
+	if e, ok := obj["error"].(map[string]interface{}); ok {
+		normalized["error"] = map[string]interface{}{"code": e["code"]}
+	}
+
+	return normalized
+}
@@ -0,0 +1,80 @@
+package conformance_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/TomChv/jsonrpc2/common"
+	"github.com/TomChv/jsonrpc2/conformance"
+	"github.com/TomChv/jsonrpc2/server"
+)
+
+type example struct{}
+
+func (e *example) Sum(args []int) int {
+	res := 0
+	for _, arg := range args {
+		res += arg
+	}
+	return res
+}
+
+func (e *example) Update(_ []int) error {
+	return nil
+}
+
+type notifyExample struct{}
+
+func (e *notifyExample) Hello(_ int) error {
+	return nil
+}
+
+func (e *notifyExample) Sum(_ []int) error {
+	return nil
+}
+
+type getExample struct{}
+
+func (e *getExample) Data() []interface{} {
+	return []interface{}{"hello", 5}
+}
+
+// subtract accepts both positional and named params
+func subtract(_ context.Context, req *common.Request) (interface{}, *common.RpcError) {
+	params, _ := req.Params.(json.RawMessage)
+
+	var named struct {
+		Minuend    int `json:"minuend"`
+		Subtrahend int `json:"subtrahend"`
+	}
+	if err := json.Unmarshal(params, &named); err == nil {
+		return named.Minuend - named.Subtrahend, nil
+	}
+
+	var positional [2]int
+	if err := json.Unmarshal(params, &positional); err != nil {
+		return nil, server.InvalidParamsError(err)
+	}
+	return positional[0] - positional[1], nil
+}
+
+func TestStrictServer(t *testing.T) {
+	s := server.New(context.TODO()).SetStrict(true)
+
+	for namespace, service := range map[string]interface{}{
+		"":       &example{},
+		"notify": &notifyExample{},
+		"get":    &getExample{},
+	} {
+		if err := s.Register(namespace, service); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.RegisterHandler("subtract", server.HandlerFunc(subtract)); err != nil {
+		t.Fatal(err)
+	}
+
+	conformance.Run(t, s)
+}
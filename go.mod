@@ -1,11 +1,8 @@
 module github.com/TomChv/jsonrpc2
 
-go 1.17
+go 1.18
 
-require (
-	github.com/PtitLuca/go-dispatcher v1.0.3
-	github.com/stretchr/testify v1.8.0
-)
+require github.com/stretchr/testify v1.8.0
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
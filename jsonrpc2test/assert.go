@@ -0,0 +1,161 @@
+package jsonrpc2test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/TomChv/jsonrpc2/common"
+)
+
+// response is a decoded response, result is kept raw to tell a null result
+// from a missing one
+type response struct {
+	Result json.RawMessage  `json:"result"`
+	Error  *common.RpcError `json:"error"`
+}
+
+// AssertResult check that body is a successful response whose result equals
+// expected once encoded in JSON
+func AssertResult(tb testing.TB, body []byte, expected interface{}) bool {
+	tb.Helper()
+
+	var res response
+	if err := json.Unmarshal(body, &res); err != nil {
+		tb.Errorf("jsonrpc2test: invalid response %s: %v", body, err)
+		return false
+	}
+
+	data, err := json.Marshal(expected)
+	if err != nil {
+		tb.Errorf("jsonrpc2test: invalid expected result: %v", err)
+		return false
+	}
+
+	if res.Error != nil {
+		tb.Errorf("jsonrpc2test: expected result %s, got error %d %s", data, res.Error.Code, res.Error.Message)
+		return false
+	}
+
+	if !equalJSON(data, res.Result) {
+		tb.Errorf("jsonrpc2test: expected result %s, got %s", data, res.Result)
+		return false
+	}
+	return true
+}
+
+// AssertError check that body is an error response with code
+func AssertError(tb testing.TB, body []byte, code int64) bool {
+	tb.Helper()
+
+	var res response
+	if err := json.Unmarshal(body, &res); err != nil {
+		tb.Errorf("jsonrpc2test: invalid response %s: %v", body, err)
+		return false
+	}
+
+	if res.Error == nil {
+		tb.Errorf("jsonrpc2test: expected error %d, got result %s", code, res.Result)
+		return false
+	}
+
+	if res.Error.Code != code {
+		tb.Errorf("jsonrpc2test: expected error %d, got %d %s", code, res.Error.Code, res.Error.Message)
+		return false
+	}
+	return true
+}
+
+// AssertCallError check that err is a *common.RpcError with code, as
+// returned by client.Client
+func AssertCallError(tb testing.TB, err error, code int64) bool {
+	tb.Helper()
+
+	var rpcErr *common.RpcError
+	if !errors.As(err, &rpcErr) {
+		tb.Errorf("jsonrpc2test: expected error %d, got %v", code, err)
+		return false
+	}
+
+	if rpcErr.Code != code {
+		tb.Errorf("jsonrpc2test: expected error %d, got %d %s", code, rpcErr.Code, rpcErr.Message)
+		return false
+	}
+	return true
+}
+
+// AssertNoResponse check that nothing was sent back, as for notifications
+func AssertNoResponse(tb testing.TB, body []byte) bool {
+	tb.Helper()
+
+	if len(body) != 0 {
+		tb.Errorf("jsonrpc2test: expected no response, got %s", body)
+		return false
+	}
+	return true
+}
+
+// AssertResponse check that body equals the expected raw response once
+// decoded. Responses of a batch may be in any order.
+func AssertResponse(tb testing.TB, expected string, body []byte) bool {
+	tb.Helper()
+
+	e, err := decode([]byte(expected))
+	if err != nil {
+		tb.Errorf("jsonrpc2test: invalid expected response: %v", err)
+		return false
+	}
+
+	a, err := decode(body)
+	if err != nil || !reflect.DeepEqual(sortBatch(e), sortBatch(a)) {
+		tb.Errorf("jsonrpc2test: expected response %s, got %s", expected, body)
+		return false
+	}
+	return true
+}
+
+// equalJSON compare two JSON documents
+func equalJSON(expected, actual []byte) bool {
+	e, err := decode(expected)
+	if err != nil {
+		return false
+	}
+	a, err := decode(actual)
+	if err != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(e, a)
+}
+
+// sortBatch sort the responses of a decoded batch by their encoding, other
+// values are returned as is
+func sortBatch(v interface{}) interface{} {
+	batch, ok := v.([]interface{})
+	if !ok {
+		return v
+	}
+
+	type entry struct {
+		key string
+		res interface{}
+	}
+
+	entries := make([]entry, len(batch))
+	for i, res := range batch {
+		// Maps are encoded with sorted keys
+		data, _ := json.Marshal(res)
+		entries[i] = entry{key: string(data), res: res}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].key < entries[j].key
+	})
+
+	sorted := make([]interface{}, len(entries))
+	for i, e := range entries {
+		sorted[i] = e.res
+	}
+	return sorted
+}
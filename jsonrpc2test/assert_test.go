@@ -0,0 +1,95 @@
+package jsonrpc2test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/TomChv/jsonrpc2/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssertResult(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		expected interface{}
+		ok       bool
+	}{
+		{name: "Equal result", body: `{"jsonrpc": "2.0", "result": {"a": [1, 2]}, "id": 1}`, expected: map[string][]int{"a": {1, 2}}, ok: true},
+		{name: "Null result", body: `{"jsonrpc": "2.0", "result": null, "id": 1}`, expected: nil, ok: true},
+		{name: "Different result", body: `{"jsonrpc": "2.0", "result": [2, 1], "id": 1}`, expected: []int{1, 2}, ok: false},
+		{name: "Error", body: `{"jsonrpc": "2.0", "error": {"code": -32601, "message": "Method not found"}, "id": 1}`, expected: 1, ok: false},
+		{name: "Invalid response", body: `{`, expected: 1, ok: false},
+		{name: "Invalid expected result", body: `{"jsonrpc": "2.0", "result": 1, "id": 1}`, expected: make(chan int), ok: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := &fakeTB{}
+			assert.Equal(t, tt.ok, AssertResult(tb, []byte(tt.body), tt.expected))
+			assert.Equal(t, tt.ok, len(tb.errors) == 0)
+		})
+	}
+}
+
+func TestAssertError(t *testing.T) {
+	testCases := []struct {
+		name string
+		body string
+		code int64
+		ok   bool
+	}{
+		{name: "Same code", body: `{"jsonrpc": "2.0", "error": {"code": -32601, "message": "Method not found"}, "id": 1}`, code: -32601, ok: true},
+		{name: "Different code", body: `{"jsonrpc": "2.0", "error": {"code": -32601, "message": "Method not found"}, "id": 1}`, code: -32602, ok: false},
+		{name: "Result", body: `{"jsonrpc": "2.0", "result": 1, "id": 1}`, code: -32601, ok: false},
+		{name: "Invalid response", body: ``, code: -32601, ok: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := &fakeTB{}
+			assert.Equal(t, tt.ok, AssertError(tb, []byte(tt.body), tt.code))
+			assert.Equal(t, tt.ok, len(tb.errors) == 0)
+		})
+	}
+}
+
+func TestAssertCallError(t *testing.T) {
+	tb := &fakeTB{}
+	assert.True(t, AssertCallError(tb, server.InternalError(errors.New("foo")), -32603))
+	assert.False(t, AssertCallError(tb, server.InternalError(errors.New("foo")), -32600))
+	assert.False(t, AssertCallError(tb, errors.New("foo"), -32603))
+	assert.False(t, AssertCallError(tb, nil, -32603))
+	assert.Len(t, tb.errors, 3)
+}
+
+func TestAssertNoResponse(t *testing.T) {
+	tb := &fakeTB{}
+	assert.True(t, AssertNoResponse(tb, nil))
+	assert.False(t, AssertNoResponse(tb, []byte(`{}`)))
+	assert.Len(t, tb.errors, 1)
+}
+
+func TestAssertResponse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected string
+		body     string
+		ok       bool
+	}{
+		{name: "Same response", expected: `{"jsonrpc": "2.0", "result": 1, "id": 1}`, body: `{"id":1,"jsonrpc":"2.0","result":1}`, ok: true},
+		{name: "Different response", expected: `{"jsonrpc": "2.0", "result": 1, "id": 1}`, body: `{"jsonrpc":"2.0","result":1,"id":2}`, ok: false},
+		{name: "Batch in any order", expected: `[{"result": 1, "id": 1}, {"result": 2, "id": 2}]`, body: `[{"result": 2, "id": 2}, {"result": 1, "id": 1}]`, ok: true},
+		{name: "Result order matters", expected: `{"result": [1, 2], "id": 1}`, body: `{"result": [2, 1], "id": 1}`, ok: false},
+		{name: "Invalid expected response", expected: `{`, body: `{}`, ok: false},
+		{name: "Invalid response", expected: `{}`, body: `{`, ok: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := &fakeTB{}
+			assert.Equal(t, tt.ok, AssertResponse(tb, tt.expected, []byte(tt.body)))
+			assert.Equal(t, tt.ok, len(tb.errors) == 0)
+		})
+	}
+}
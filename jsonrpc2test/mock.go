@@ -0,0 +1,193 @@
+package jsonrpc2test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/TomChv/jsonrpc2/common"
+	"github.com/TomChv/jsonrpc2/server"
+)
+
+var ErrUnexpectedCall = errors.New("unexpected call")
+
+// Mock is a scriptable server, it answers the calls it expects with canned
+// results or errors.
+//
+// Unexpected calls fail the test and get a Method not found error.
+// Expectations that are not met when the test ends fail the test.
+type Mock struct {
+	*Server
+
+	tb           testing.TB
+	expectations []*Expectation
+	l            sync.Mutex
+}
+
+// Expectation is a call expected by a Mock
+type Expectation struct {
+	method string
+	params interface{}
+
+	result interface{}
+	err    *common.RpcError
+
+	times    int
+	anyTimes bool
+	calls    int
+
+	// l is the lock of the Mock, expectations may be changed while calls
+	// are handled
+	l *sync.Mutex
+}
+
+// NewMock create a Mock without expectation
+func NewMock(tb testing.TB) *Mock {
+	m := &Mock{tb: tb}
+
+	rpc := server.New(context.Background()).SetLogger(server.NopLogger{})
+	if err := rpc.SetFallback(m); err != nil {
+		tb.Fatalf("jsonrpc2test: set fallback: %v", err)
+	}
+	m.Server = newServer(rpc)
+
+	tb.Cleanup(func() {
+		m.AssertExpectations(tb)
+	})
+
+	return m
+}
+
+// Expect a call of method with params.
+// Params are compared once encoded in JSON, nil params match any params.
+// The call is expected once and returns a null result, see Expectation.
+func (m *Mock) Expect(method string, params interface{}) *Expectation {
+	e := &Expectation{method: method, params: params, times: 1, l: &m.l}
+
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	m.expectations = append(m.expectations, e)
+	return e
+}
+
+// Return set the result of the expected call
+func (e *Expectation) Return(result interface{}) *Expectation {
+	e.l.Lock()
+	defer e.l.Unlock()
+
+	e.result = result
+	return e
+}
+
+// ReturnError set the error of the expected call
+func (e *Expectation) ReturnError(err *common.RpcError) *Expectation {
+	e.l.Lock()
+	defer e.l.Unlock()
+
+	e.err = err
+	return e
+}
+
+// Times set how many times the call is expected
+func (e *Expectation) Times(n int) *Expectation {
+	e.l.Lock()
+	defer e.l.Unlock()
+
+	e.times = n
+	return e
+}
+
+// AnyTimes allow the call to be made any number of times, including none
+func (e *Expectation) AnyTimes() *Expectation {
+	e.l.Lock()
+	defer e.l.Unlock()
+
+	e.anyTimes = true
+	return e
+}
+
+// Handle answer a call with the first expectation it matches
+func (m *Mock) Handle(ctx context.Context, req *common.Request) (interface{}, *common.RpcError) {
+	params, _ := req.Params.(json.RawMessage)
+
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	for _, e := range m.expectations {
+		if e.method != req.Method || (!e.anyTimes && e.calls >= e.times) || !matchParams(e.params, params) {
+			continue
+		}
+
+		e.calls++
+		return e.result, e.err
+	}
+
+	m.tb.Errorf("jsonrpc2test: unexpected call of %s with params %s", req.Method, params)
+	return nil, server.MethodNotFoundError(ErrUnexpectedCall)
+}
+
+// AssertExpectations fail the test if an expected call was not made enough
+// times. It is called when the test ends.
+func (m *Mock) AssertExpectations(tb testing.TB) bool {
+	tb.Helper()
+
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	ok := true
+	for _, e := range m.expectations {
+		if !e.anyTimes && e.calls != e.times {
+			tb.Errorf("jsonrpc2test: expected %d call(s) of %s with params %s, got %d", e.times, e.method, describe(e.params), e.calls)
+			ok = false
+		}
+	}
+
+	return ok
+}
+
+// matchParams compare expected params with the raw params of a call
+func matchParams(expected interface{}, params json.RawMessage) bool {
+	if expected == nil {
+		return true
+	}
+
+	data, err := json.Marshal(expected)
+	if err != nil {
+		return false
+	}
+
+	return equalJSON(data, params)
+}
+
+// describe format expected params for failure messages
+func describe(params interface{}) string {
+	if params == nil {
+		return "any"
+	}
+
+	data, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Sprint(params)
+	}
+	return string(data)
+}
+
+// decode raw JSON, numbers are kept as json.Number so they are compared
+// exactly. Empty data is decoded as null.
+func decode(data []byte) (interface{}, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+	err := dec.Decode(&v)
+	return v, err
+}
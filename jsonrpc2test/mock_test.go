@@ -0,0 +1,85 @@
+package jsonrpc2test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/TomChv/jsonrpc2/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMock(t *testing.T) {
+	m := NewMock(t)
+	m.Expect("sum", []int{1, 2}).Return(3)
+	m.Expect("sum", []int{1, 2}).Return(4)
+	m.Expect("divide", []int{1, 0}).ReturnError(server.InvalidParamsError(errors.New("division by zero")))
+	m.Expect("ping", nil).Return("pong").Times(2)
+	m.Expect("notify", map[string]string{"msg": "hello"})
+	m.Expect("status", nil).AnyTimes()
+
+	var sum int
+	assert.Nil(t, m.Client.Call(context.TODO(), "sum", []int{1, 2}, &sum))
+	assert.Equal(t, 3, sum)
+	assert.Nil(t, m.Client.Call(context.TODO(), "sum", []int{1, 2}, &sum))
+	assert.Equal(t, 4, sum)
+
+	AssertCallError(t, m.Client.Call(context.TODO(), "divide", []int{1, 0}, nil), -32602)
+
+	for _, params := range []interface{}{nil, []string{"foo"}} {
+		var pong string
+		assert.Nil(t, m.Client.Call(context.TODO(), "ping", params, &pong))
+		assert.Equal(t, "pong", pong)
+	}
+
+	assert.Nil(t, m.Client.Notify(context.TODO(), "notify", map[string]string{"msg": "hello"}))
+
+	AssertResponse(t, `[
+		{"jsonrpc": "2.0", "result": null, "id": 2},
+		{"jsonrpc": "2.0", "result": null, "id": 1}
+	]`, m.Post(`[
+		{"jsonrpc": "2.0", "method": "status", "id": 1},
+		{"jsonrpc": "2.0", "method": "status", "id": 2}
+	]`))
+}
+
+func TestMock_Failures(t *testing.T) {
+	tb := &fakeTB{}
+
+	m := NewMock(tb)
+	m.Expect("sum", []int{1, 2}).Return(3)
+	m.Expect("ping", nil).Times(2)
+	m.Expect("status", nil).AnyTimes()
+
+	// Params do not match
+	AssertCallError(t, m.Client.Call(context.TODO(), "sum", []int{2, 1}, nil), -32601)
+	assert.Equal(t, []string{"jsonrpc2test: unexpected call of sum with params [2,1]"}, tb.errors)
+
+	assert.Nil(t, m.Client.Call(context.TODO(), "ping", nil, nil))
+
+	tb.errors = nil
+	tb.end()
+	assert.Equal(t, []string{
+		"jsonrpc2test: expected 1 call(s) of sum with params [1,2], got 0",
+		"jsonrpc2test: expected 2 call(s) of ping with params any, got 1",
+	}, tb.errors)
+}
+
+func TestMock_ExpectWhileCalled(t *testing.T) {
+	m := NewMock(t)
+	e := m.Expect("status", nil).AnyTimes()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			assert.Nil(t, m.Client.Call(context.TODO(), "status", nil, nil))
+		}
+	}()
+
+	// Expectations may be changed while calls are handled
+	for i := 0; i < 10; i++ {
+		e.Return(i).AnyTimes()
+	}
+	<-done
+}
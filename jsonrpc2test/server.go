@@ -0,0 +1,136 @@
+// Package jsonrpc2test provides utilities to test JSON-RPC 2.0 services and
+// clients without network boilerplate :
+//   - NewServer runs services in-process and returns a ready client
+//   - NewMock is a scriptable server that expects calls and returns canned
+//     results or errors
+//   - Assert helpers check raw JSON-RPC responses
+package jsonrpc2test
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/TomChv/jsonrpc2/client"
+	"github.com/TomChv/jsonrpc2/server"
+)
+
+const (
+	// URL is the address of in-process servers, requests sent to it never
+	// reach the network
+	URL = "http://jsonrpc2test/"
+
+	// RemoteAddr is the address of the callers of in-process servers
+	RemoteAddr = "192.0.2.1:1234"
+)
+
+// Service is a service registered under Namespace, see
+// server.JsonRPC2.Register
+type Service struct {
+	Namespace string
+	Service   interface{}
+}
+
+// Server is a JSON-RPC 2.0 server running in-process
+type Server struct {
+	// RPC is the server, it may be configured before sending calls
+	RPC *server.JsonRPC2
+
+	// Client calls RPC in-process
+	Client *client.Client
+}
+
+// NewServer create a server with services registered.
+// Server logs are written to the test log, registration errors fail the
+// test.
+func NewServer(tb testing.TB, services ...Service) *Server {
+	tb.Helper()
+
+	// Logs of calls still running when the test ends are dropped
+	w := &testWriter{tb: tb}
+	tb.Cleanup(w.close)
+
+	rpc := server.New(context.Background()).
+		SetLogger(server.NewStdLogger(log.New(w, "", 0)))
+
+	for _, s := range services {
+		if err := rpc.Register(s.Namespace, s.Service); err != nil {
+			tb.Fatalf("jsonrpc2test: register %s: %v", s.Namespace, err)
+		}
+	}
+
+	return newServer(rpc)
+}
+
+// newServer wrap rpc with a client calling it in-process
+func newServer(rpc *server.JsonRPC2) *Server {
+	c := client.New(URL).SetHTTPClient(&http.Client{
+		Transport: Transport{Handler: rpc},
+	})
+
+	return &Server{RPC: rpc, Client: c}
+}
+
+// Post send a raw request or batch to the server and return the raw
+// response body, it is empty for notifications
+func (s *Server) Post(body string) []byte {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	s.RPC.ServeHTTP(w, req)
+
+	return w.Body.Bytes()
+}
+
+// Transport is an http.RoundTripper that serves requests in-process with
+// Handler
+type Transport struct {
+	Handler http.Handler
+}
+
+func (t Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	// The request is given to a server handler, as if it was received
+	req := r.Clone(r.Context())
+	req.RemoteAddr = RemoteAddr
+	req.RequestURI = r.URL.RequestURI()
+	if req.Body == nil {
+		req.Body = ioutil.NopCloser(strings.NewReader(""))
+	}
+
+	w := httptest.NewRecorder()
+	t.Handler.ServeHTTP(w, req)
+
+	return w.Result(), nil
+}
+
+// testWriter writes log lines to the test log until it is closed, logging
+// after the end of a test panics
+type testWriter struct {
+	tb     testing.TB
+	closed bool
+	l      sync.Mutex
+}
+
+func (w *testWriter) Write(p []byte) (int, error) {
+	w.l.Lock()
+	defer w.l.Unlock()
+
+	if !w.closed {
+		w.tb.Logf("%s", strings.TrimSuffix(string(p), "\n"))
+	}
+	return len(p), nil
+}
+
+// close drop the lines written from now on
+func (w *testWriter) close() {
+	w.l.Lock()
+	defer w.l.Unlock()
+
+	w.closed = true
+}
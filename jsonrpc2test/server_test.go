@@ -0,0 +1,118 @@
+package jsonrpc2test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/TomChv/jsonrpc2/server"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeTB records the failures and logs of a test
+type fakeTB struct {
+	testing.TB
+
+	errors   []string
+	logs     []string
+	cleanups []func()
+}
+
+func (tb *fakeTB) Helper() {}
+
+func (tb *fakeTB) Errorf(format string, args ...interface{}) {
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}
+
+func (tb *fakeTB) Fatalf(format string, args ...interface{}) {
+	tb.Errorf(format, args...)
+}
+
+func (tb *fakeTB) Logf(format string, args ...interface{}) {
+	tb.logs = append(tb.logs, fmt.Sprintf(format, args...))
+}
+
+func (tb *fakeTB) Cleanup(f func()) {
+	tb.cleanups = append(tb.cleanups, f)
+}
+
+// end run cleanups as if the test ended
+func (tb *fakeTB) end() {
+	for i := len(tb.cleanups) - 1; i >= 0; i-- {
+		tb.cleanups[i]()
+	}
+}
+
+type mockService struct{}
+
+func (ms *mockService) Sum(a, b int) int {
+	return a + b
+}
+
+func (ms *mockService) Remote(ctx context.Context) string {
+	return server.HTTPRequestFromContext(ctx).RemoteAddr
+}
+
+type mockInvalidService struct{}
+
+func (ms *mockInvalidService) Invalid(c chan int) {}
+
+func TestNewServer(t *testing.T) {
+	s := NewServer(t, Service{Namespace: "mock", Service: &mockService{}})
+
+	var sum int
+	assert.Nil(t, s.Client.Call(context.TODO(), "mock_sum", []int{1, 2}, &sum))
+	assert.Equal(t, 3, sum)
+
+	var remote string
+	assert.Nil(t, s.Client.Call(context.TODO(), "mock_remote", nil, &remote))
+	assert.Equal(t, RemoteAddr, remote)
+
+	AssertCallError(t, s.Client.Call(context.TODO(), "mock_unknown", nil, nil), -32601)
+	AssertResult(t, s.Post(`{"jsonrpc": "2.0", "method": "mock_sum", "params": [2, 3], "id": 1}`), 5)
+	AssertNoResponse(t, s.Post(`{"jsonrpc": "2.0", "method": "mock_sum", "params": [2, 3]}`))
+}
+
+func TestNewServer_Configure(t *testing.T) {
+	s := NewServer(t, Service{Namespace: "mock", Service: &mockService{}})
+	s.RPC.SetHTTPOptions(server.HTTPOptions{StatusCodes: true})
+
+	err := s.Client.Call(context.TODO(), "mock_unknown", nil, nil)
+	AssertCallError(t, err, -32601)
+}
+
+func TestNewServer_Errors(t *testing.T) {
+	tb := &fakeTB{}
+	s := NewServer(tb, Service{Namespace: "invalid", Service: &mockInvalidService{}})
+	assert.Len(t, tb.errors, 1)
+	assert.True(t, strings.HasPrefix(tb.errors[0], "jsonrpc2test: register invalid: "))
+
+	// Server logs are written to the test log
+	s.Post(`{"jsonrpc": "2.0", "method": "mock_sum", "id": 1}`)
+	assert.NotEmpty(t, tb.logs)
+	assert.Contains(t, tb.logs[len(tb.logs)-1], "call failed method=mock_sum")
+
+	// Logs are dropped once the test ended
+	logs := len(tb.logs)
+	tb.end()
+	s.Post(`{"jsonrpc": "2.0", "method": "mock_sum", "id": 1}`)
+	assert.Len(t, tb.logs, logs)
+}
+
+func TestTransport(t *testing.T) {
+	c := &http.Client{Transport: Transport{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, "%s %s %s", r.Method, r.RequestURI, r.RemoteAddr)
+	})}}
+
+	res, err := c.Get(URL + "foo?bar=1")
+	assert.Nil(t, err)
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, "GET /foo?bar=1 "+RemoteAddr, string(body))
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
@@ -0,0 +1,160 @@
+package redact
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidPath = errors.New("invalid redaction path")
+
+// Roots of paths
+const (
+	RootParams = "params"
+	RootResult = "result"
+)
+
+// Path is a JSON path to a value to redact, rooted at the params or the
+// result of a call :
+//
+//	params.password
+//	params[0].credentials.token
+//	params[*].secret
+//	result.*.apiKey
+//
+// A leading "$." is accepted. A "*" matches every member of an object or
+// every element of an array.
+type Path struct {
+	root     string
+	segments []segment
+}
+
+// segment is a step of a Path, it selects a member or an element
+type segment struct {
+	key   string
+	index int
+	any   bool
+}
+
+// ParsePath parse a path, it returns ErrInvalidPath if the path is malformed
+// or not rooted at params or result
+func ParsePath(str string) (Path, error) {
+	s := strings.TrimPrefix(strings.TrimPrefix(str, "$"), ".")
+
+	root := s
+	if i := strings.IndexAny(s, ".["); i >= 0 {
+		root = s[:i]
+	}
+	if root != RootParams && root != RootResult {
+		return Path{}, ErrInvalidPath
+	}
+
+	p := Path{root: root}
+	for s = s[len(root):]; s != ""; {
+		var seg segment
+		var err error
+
+		switch s[0] {
+		case '.':
+			seg, s, err = parseMember(s[1:])
+		case '[':
+			seg, s, err = parseIndex(s[1:])
+		default:
+			err = ErrInvalidPath
+		}
+		if err != nil {
+			return Path{}, err
+		}
+
+		p.segments = append(p.segments, seg)
+	}
+
+	return p, nil
+}
+
+// parseMember parse a member name up to the next segment
+func parseMember(s string) (segment, string, error) {
+	end := strings.IndexAny(s, ".[")
+	if end < 0 {
+		end = len(s)
+	}
+
+	key := s[:end]
+	if key == "" {
+		return segment{}, "", ErrInvalidPath
+	}
+	if key == "*" {
+		return segment{any: true}, s[end:], nil
+	}
+	return segment{key: key, index: -1}, s[end:], nil
+}
+
+// parseIndex parse an array index or a wildcard followed by a closing bracket
+func parseIndex(s string) (segment, string, error) {
+	end := strings.IndexByte(s, ']')
+	if end < 0 {
+		return segment{}, "", ErrInvalidPath
+	}
+
+	if s[:end] == "*" {
+		return segment{any: true}, s[end+1:], nil
+	}
+
+	index, err := strconv.Atoi(s[:end])
+	if err != nil || index < 0 {
+		return segment{}, "", ErrInvalidPath
+	}
+	return segment{index: index}, s[end+1:], nil
+}
+
+// Root return the root of the path, RootParams or RootResult
+func (p Path) Root() string {
+	return p.root
+}
+
+func (p Path) String() string {
+	var b strings.Builder
+
+	b.WriteString(p.root)
+	for _, seg := range p.segments {
+		switch {
+		case seg.any:
+			b.WriteString("[*]")
+		case seg.index >= 0:
+			b.WriteString("[" + strconv.Itoa(seg.index) + "]")
+		default:
+			b.WriteString("." + seg.key)
+		}
+	}
+
+	return b.String()
+}
+
+// redact replace the values of v selected by segments with Mask
+func redact(v interface{}, segments []segment) interface{} {
+	if len(segments) == 0 {
+		return Mask
+	}
+
+	seg, rest := segments[0], segments[1:]
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if seg.any {
+			for k, e := range v {
+				v[k] = redact(e, rest)
+			}
+		} else if e, ok := v[seg.key]; ok && seg.index < 0 {
+			v[seg.key] = redact(e, rest)
+		}
+	case []interface{}:
+		if seg.any {
+			for i, e := range v {
+				v[i] = redact(e, rest)
+			}
+		} else if seg.index >= 0 && seg.index < len(v) {
+			v[seg.index] = redact(v[seg.index], rest)
+		}
+	}
+
+	return v
+}
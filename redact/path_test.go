@@ -0,0 +1,47 @@
+package redact
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePath(t *testing.T) {
+	testCases := []struct {
+		name     string
+		path     string
+		expected string
+		err      error
+	}{
+		{name: "Root", path: "params", expected: "params"},
+		{name: "Member", path: "params.password", expected: "params.password"},
+		{name: "Index", path: "params[0].token", expected: "params[0].token"},
+		{name: "Nested", path: "result.user.credentials[2]", expected: "result.user.credentials[2]"},
+		{name: "Wildcard member", path: "params.*.secret", expected: "params[*].secret"},
+		{name: "Wildcard index", path: "params[*].secret", expected: "params[*].secret"},
+		{name: "Dollar prefix", path: "$.params.password", expected: "params.password"},
+		{name: "Unknown root", path: "body.password", err: ErrInvalidPath},
+		{name: "Empty", path: "", err: ErrInvalidPath},
+		{name: "Empty member", path: "params..password", err: ErrInvalidPath},
+		{name: "Unclosed index", path: "params[0", err: ErrInvalidPath},
+		{name: "Negative index", path: "params[-1]", err: ErrInvalidPath},
+		{name: "Invalid index", path: "params[a]", err: ErrInvalidPath},
+		{name: "Missing separator", path: "params[0]token", err: ErrInvalidPath},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParsePath(tt.path)
+			assert.Equal(t, tt.err, err)
+			if tt.err == nil {
+				assert.Equal(t, tt.expected, p.String())
+			}
+		})
+	}
+}
+
+func TestPath_Root(t *testing.T) {
+	p, err := ParsePath("result[0]")
+	assert.Nil(t, err)
+	assert.Equal(t, RootResult, p.Root())
+}
@@ -0,0 +1,127 @@
+// Package redact hides sensitive params and results, such as passwords and
+// tokens, from the logs and traces of the server and client.
+//
+// Values are selected by JSON paths given per method, or by tagging struct
+// fields with `rpc:"secret"`.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"path"
+	"sync"
+)
+
+var ErrInvalidPattern = errors.New("invalid method pattern")
+
+// Mask replaces redacted values
+const Mask = "[REDACTED]"
+
+// masked is Mask encoded in JSON
+var masked = json.RawMessage(`"` + Mask + `"`)
+
+// Redactor holds the redaction rules of methods.
+// A nil Redactor has no rule.
+type Redactor struct {
+	rules []rule
+	l     sync.RWMutex
+}
+
+// rule redact paths of the methods matching pattern
+type rule struct {
+	pattern string
+	paths   []Path
+}
+
+// New create a Redactor without rule
+func New() *Redactor {
+	return &Redactor{}
+}
+
+// Add redact paths in the calls of the methods matching pattern.
+// Pattern follows path.Match syntax.
+func (r *Redactor) Add(pattern string, paths ...string) error {
+	if pattern == "" {
+		return ErrInvalidPattern
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return ErrInvalidPattern
+	}
+
+	ru := rule{pattern: pattern}
+	for _, p := range paths {
+		parsed, err := ParsePath(p)
+		if err != nil {
+			return err
+		}
+		ru.paths = append(ru.paths, parsed)
+	}
+
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	r.rules = append(r.rules, ru)
+	return nil
+}
+
+// Paths return the paths of every rule matching method
+func (r *Redactor) Paths(method string) []Path {
+	if r == nil {
+		return nil
+	}
+
+	r.l.RLock()
+	defer r.l.RUnlock()
+
+	var paths []Path
+	for _, ru := range r.rules {
+		if ok, _ := path.Match(ru.pattern, method); ok {
+			paths = append(paths, ru.paths...)
+		}
+	}
+	return paths
+}
+
+// Apply return a copy of data where the values selected by the paths rooted
+// at root are replaced with Mask.
+// Data that is not valid JSON is entirely masked if a path applies to it.
+func Apply(root string, data json.RawMessage, paths []Path) json.RawMessage {
+	var applied []Path
+	for _, p := range paths {
+		if p.root == root {
+			applied = append(applied, p)
+		}
+	}
+	if len(applied) == 0 {
+		return data
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return masked
+	}
+
+	for _, p := range applied {
+		v = redact(v, p.segments)
+	}
+
+	res, err := json.Marshal(v)
+	if err != nil {
+		return masked
+	}
+	return res
+}
+
+// Value encode v in JSON then apply the paths rooted at root, see Apply.
+// Values that can not be encoded are masked.
+func Value(root string, v interface{}, paths []Path) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return masked
+	}
+	return Apply(root, data, paths)
+}
@@ -0,0 +1,116 @@
+package redact
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustPaths(t *testing.T, paths ...string) []Path {
+	var res []Path
+	for _, p := range paths {
+		parsed, err := ParsePath(p)
+		assert.Nil(t, err)
+		res = append(res, parsed)
+	}
+	return res
+}
+
+func TestRedactor_Add(t *testing.T) {
+	r := New()
+	assert.Equal(t, ErrInvalidPattern, r.Add(""))
+	assert.Equal(t, ErrInvalidPattern, r.Add("auth_[", "params.password"))
+	assert.Equal(t, ErrInvalidPath, r.Add("auth_login", "password"))
+
+	assert.Nil(t, r.Add("auth_*", "params.password"))
+	assert.Nil(t, r.Add("auth_login", "result.token", "params[1]"))
+
+	assert.Equal(t, mustPaths(t, "params.password", "result.token", "params[1]"), r.Paths("auth_login"))
+	assert.Equal(t, mustPaths(t, "params.password"), r.Paths("auth_logout"))
+	assert.Nil(t, r.Paths("user_get"))
+
+	var nilRedactor *Redactor
+	assert.Nil(t, nilRedactor.Paths("auth_login"))
+}
+
+func TestApply(t *testing.T) {
+	testCases := []struct {
+		name     string
+		root     string
+		data     string
+		paths    []string
+		expected string
+	}{
+		{
+			name:     "No path",
+			root:     RootParams,
+			data:     `{"password": "foo"}`,
+			expected: `{"password": "foo"}`,
+		},
+		{
+			name:     "Path of another root",
+			root:     RootParams,
+			data:     `{"token": "foo"}`,
+			paths:    []string{"result.token"},
+			expected: `{"token": "foo"}`,
+		},
+		{
+			name:     "Member",
+			root:     RootParams,
+			data:     `{"user": "bob", "password": "foo"}`,
+			paths:    []string{"params.password"},
+			expected: `{"password":"[REDACTED]","user":"bob"}`,
+		},
+		{
+			name:     "Nested member of positional params",
+			root:     RootParams,
+			data:     `["bob", {"credentials": {"token": "foo", "kind": "bearer"}}]`,
+			paths:    []string{"params[1].credentials.token"},
+			expected: `["bob",{"credentials":{"kind":"bearer","token":"[REDACTED]"}}]`,
+		},
+		{
+			name:     "Whole value",
+			root:     RootParams,
+			data:     `["bob", {"token": "foo"}]`,
+			paths:    []string{"params[1]"},
+			expected: `["bob","[REDACTED]"]`,
+		},
+		{
+			name:     "Wildcards",
+			root:     RootResult,
+			data:     `{"a": [{"key": 1}, {"key": 2}], "b": [{"key": 3}]}`,
+			paths:    []string{"result.*[*].key"},
+			expected: `{"a":[{"key":"[REDACTED]"},{"key":"[REDACTED]"}],"b":[{"key":"[REDACTED]"}]}`,
+		},
+		{
+			name:     "Missing members are left untouched",
+			root:     RootParams,
+			data:     `[{"user": "bob"}, 12345678901234567890]`,
+			paths:    []string{"params[0].password", "params[3]", "params.password"},
+			expected: `[{"user":"bob"},12345678901234567890]`,
+		},
+		{
+			name:     "Invalid JSON is masked",
+			root:     RootParams,
+			data:     `{"password": `,
+			paths:    []string{"params.password"},
+			expected: `"[REDACTED]"`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			res := Apply(tt.root, json.RawMessage(tt.data), mustPaths(t, tt.paths...))
+			assert.Equal(t, tt.expected, string(res))
+		})
+	}
+}
+
+func TestValue(t *testing.T) {
+	res := Value(RootResult, map[string]string{"token": "foo"}, mustPaths(t, "result.token"))
+	assert.Equal(t, `{"token":"[REDACTED]"}`, string(res))
+
+	res = Value(RootResult, make(chan int), mustPaths(t, "result.token"))
+	assert.Equal(t, `"[REDACTED]"`, string(res))
+}
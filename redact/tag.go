@@ -0,0 +1,146 @@
+package redact
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Struct tag that marks a field as secret : `rpc:"secret"`
+const (
+	TagKey    = "rpc"
+	TagSecret = "secret"
+)
+
+// TypePaths return the paths of the fields of t tagged as secret, rooted at
+// root.
+// Fields are named after their json tag, elements of slices and maps are
+// matched with a wildcard.
+func TypePaths(root string, t reflect.Type) []Path {
+	if t == nil {
+		return nil
+	}
+
+	base, err := ParsePath(root)
+	if err != nil {
+		return nil
+	}
+
+	var paths []Path
+	for _, segments := range secrets(t, map[reflect.Type]bool{}) {
+		p := Path{root: base.root}
+		p.segments = append(append(p.segments, base.segments...), segments...)
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+// ValuePaths return the paths of the fields of v tagged as secret, rooted at
+// root.
+// The type of each element of a []interface{} is inspected, so positional
+// params built by the client are redacted as well.
+func ValuePaths(root string, v interface{}) []Path {
+	elems, ok := v.([]interface{})
+	if !ok {
+		return TypePaths(root, reflect.TypeOf(v))
+	}
+
+	var paths []Path
+	for i, e := range elems {
+		paths = append(paths, TypePaths(fmt.Sprintf("%s[%d]", root, i), reflect.TypeOf(e))...)
+	}
+	return paths
+}
+
+// secrets return the segments leading to the secret fields of t.
+// Types being visited are skipped to support recursive types.
+func secrets(t reflect.Type, visiting map[reflect.Type]bool) [][]segment {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if visiting[t] {
+			return nil
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		var res [][]segment
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+
+			name, inline, ok := fieldName(f)
+			if !ok {
+				continue
+			}
+			if inline {
+				res = append(res, secrets(f.Type, visiting)...)
+				continue
+			}
+
+			member := segment{key: name, index: -1}
+			if isSecret(f) {
+				res = append(res, []segment{member})
+				continue
+			}
+			for _, sub := range secrets(f.Type, visiting) {
+				res = append(res, append([]segment{member}, sub...))
+			}
+		}
+		return res
+	case reflect.Slice, reflect.Array, reflect.Map:
+		// Bytes are encoded as a string
+		if t.Kind() != reflect.Map && t.Elem().Kind() == reflect.Uint8 {
+			return nil
+		}
+
+		var res [][]segment
+		for _, sub := range secrets(t.Elem(), visiting) {
+			res = append(res, append([]segment{{any: true}}, sub...))
+		}
+		return res
+	default:
+		return nil
+	}
+}
+
+// fieldName return the JSON name of a struct field, inline is true for
+// embedded structs whose fields are promoted.
+// It returns false if the field is not encoded.
+func fieldName(f reflect.StructField) (name string, inline bool, ok bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+	name = strings.Split(tag, ",")[0]
+
+	if f.Anonymous && name == "" {
+		t := f.Type
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Struct {
+			return "", true, true
+		}
+	}
+
+	if !f.IsExported() {
+		return "", false, false
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return name, false, true
+}
+
+// isSecret return true if the field is tagged as secret
+func isSecret(f reflect.StructField) bool {
+	for _, opt := range strings.Split(f.Tag.Get(TagKey), ",") {
+		if opt == TagSecret {
+			return true
+		}
+	}
+	return false
+}
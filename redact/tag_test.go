@@ -0,0 +1,98 @@
+package redact
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Credentials struct {
+	User     string `json:"user"`
+	Password string `json:"password" rpc:"secret"`
+}
+
+type Embedded struct {
+	APIKey string `rpc:"secret"`
+}
+
+type Account struct {
+	Embedded
+	Credentials *Credentials            `json:"credentials"`
+	Tokens      []string                `json:"tokens" rpc:"secret"`
+	Keys        map[string]*Credentials `json:"keys"`
+	History     []Credentials           `json:"history"`
+	Ignored     Credentials             `json:"-"`
+	Parent      *Account                `json:"parent"`
+	Raw         []byte                  `json:"raw"`
+	hidden      Credentials
+}
+
+func TestTypePaths(t *testing.T) {
+	testCases := []struct {
+		name     string
+		root     string
+		t        reflect.Type
+		expected []string
+	}{
+		{name: "Nil type", root: RootParams},
+		{name: "No secret", root: RootParams, t: reflect.TypeOf("")},
+		{name: "Invalid root", root: "body", t: reflect.TypeOf(Credentials{})},
+		{
+			name:     "Struct",
+			root:     RootParams,
+			t:        reflect.TypeOf(Credentials{}),
+			expected: []string{"params.password"},
+		},
+		{
+			name:     "Pointer under index",
+			root:     "params[1]",
+			t:        reflect.TypeOf(&Credentials{}),
+			expected: []string{"params[1].password"},
+		},
+		{
+			name:     "Slice",
+			root:     RootResult,
+			t:        reflect.TypeOf([]Credentials{}),
+			expected: []string{"result[*].password"},
+		},
+		{
+			name: "Nested and recursive",
+			root: RootResult,
+			t:    reflect.TypeOf(Account{}),
+			expected: []string{
+				"result.APIKey",
+				"result.credentials.password",
+				"result.tokens",
+				"result.keys[*].password",
+				"result.history[*].password",
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			var paths []string
+			for _, p := range TypePaths(tt.root, tt.t) {
+				paths = append(paths, p.String())
+			}
+			assert.Equal(t, tt.expected, paths)
+		})
+	}
+}
+
+func TestValuePaths(t *testing.T) {
+	var paths []string
+	for _, p := range ValuePaths(RootParams, []interface{}{"bob", Credentials{}, &Credentials{}}) {
+		paths = append(paths, p.String())
+	}
+	assert.Equal(t, []string{"params[1].password", "params[2].password"}, paths)
+
+	paths = nil
+	for _, p := range ValuePaths(RootParams, &Credentials{}) {
+		paths = append(paths, p.String())
+	}
+	assert.Equal(t, []string{"params.password"}, paths)
+
+	assert.Nil(t, ValuePaths(RootParams, nil))
+}
@@ -0,0 +1,207 @@
+// Package replay records the HTTP traffic of a JSON-RPC 2.0 server and
+// replays it later against a server or a client, reporting responses that
+// changed.
+//
+// Recordings are JSON-lines files, one Exchange per line, so recorded
+// sessions can be kept as regression tests :
+//
+//	rec := replay.NewRecorder(f, s)
+//	s.Use(rec.Middleware)
+//
+//	// Later, in a test
+//	exchanges, _ := replay.Load(f)
+//	replaytest.Run(t, replay.Handler(s), exchanges, replay.Options{})
+//
+// Credentials are not recorded, replays against a server requiring them
+// send them with Options.Header.
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultSkippedHeaders are the request headers that are not recorded,
+// they carry credentials. Headers carrying API keys are named by the
+// server, see Recorder.SkipHeaders.
+var DefaultSkippedHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization", "X-Rpc-Signature"}
+
+// Exchange is a recorded HTTP request with its response
+type Exchange struct {
+	// Time is when the request was received
+	Time time.Time `json:"time"`
+
+	// Duration is the time taken to respond
+	Duration time.Duration `json:"duration"`
+
+	// Method and URL of the HTTP request, params of GET requests are
+	// redacted by the Redactor of the Recorder
+	Method string `json:"method"`
+	URL    string `json:"url"`
+
+	// Header is the metadata of the request, without skipped headers
+	Header http.Header `json:"header,omitempty"`
+
+	// Request is the body of the request, redacted by the Redactor of the
+	// Recorder
+	Request string `json:"request"`
+
+	// Status is the HTTP status code of the response
+	Status int `json:"status"`
+
+	// ResponseHeader is the metadata of the response
+	ResponseHeader http.Header `json:"responseHeader,omitempty"`
+
+	// Response is the body of the response, redacted by the Redactor of the
+	// Recorder. It is empty for notifications.
+	Response string `json:"response"`
+}
+
+// Redactor hides secrets from the URL and bodies of an exchange before it is
+// recorded. server.JsonRPC2 is a Redactor applying its redaction rules, see
+// server.AddRedaction.
+type Redactor interface {
+	RedactExchange(url string, request, response []byte) (string, []byte, []byte)
+}
+
+// Recorder writes the exchanges going through its middleware as JSON lines
+type Recorder struct {
+	// Warn is called with errors that prevented an exchange to be recorded
+	Warn func(err error)
+
+	// SkippedHeaders are the request headers that are not recorded,
+	// default is DefaultSkippedHeaders
+	SkippedHeaders []string
+
+	redactor Redactor
+	w        io.Writer
+	l        sync.Mutex
+}
+
+// NewRecorder create a Recorder writing exchanges to w, their bodies are
+// redacted by r.
+//
+// A nil r records bodies as they are: recordings then hold the passwords,
+// tokens and other secrets sent by clients and must be stored as such.
+func NewRecorder(w io.Writer, r Redactor) *Recorder {
+	return &Recorder{
+		SkippedHeaders: append([]string(nil), DefaultSkippedHeaders...),
+		redactor:       r,
+		w:              w,
+	}
+}
+
+// SkipHeaders add headers to the request headers that are not recorded,
+// such as the header carrying API keys
+func (rec *Recorder) SkipHeaders(headers ...string) *Recorder {
+	rec.SkippedHeaders = append(rec.SkippedHeaders, headers...)
+	return rec
+}
+
+// Middleware record the exchanges handled by next, see server.Middleware.
+// The request body is recorded as it is read by next, so body limits are
+// still enforced.
+func (rec *Recorder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		var body bytes.Buffer
+		r.Body = readCloser{Reader: io.TeeReader(r.Body, &body), Closer: r.Body}
+		rw := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rw, r)
+
+		uri, request, response := r.URL.RequestURI(), body.Bytes(), rw.body.Bytes()
+		if rec.redactor != nil {
+			uri, request, response = rec.redactor.RedactExchange(uri, request, response)
+		}
+
+		rec.write(&Exchange{
+			Time:           start,
+			Duration:       time.Since(start),
+			Method:         r.Method,
+			URL:            uri,
+			Header:         rec.header(r.Header),
+			Request:        string(request),
+			Status:         rw.status,
+			ResponseHeader: w.Header().Clone(),
+			Response:       string(response),
+		})
+	})
+}
+
+// header return a copy of h without skipped headers
+func (rec *Recorder) header(h http.Header) http.Header {
+	res := h.Clone()
+	for _, key := range rec.SkippedHeaders {
+		res.Del(key)
+	}
+	return res
+}
+
+// write append an exchange to the recording
+func (rec *Recorder) write(ex *Exchange) {
+	data, err := json.Marshal(ex)
+	if err == nil {
+		rec.l.Lock()
+		_, err = rec.w.Write(append(data, '\n'))
+		rec.l.Unlock()
+	}
+
+	if err != nil && rec.Warn != nil {
+		rec.Warn(fmt.Errorf("record exchange: %w", err))
+	}
+}
+
+// Load read the exchanges of a recording
+func Load(r io.Reader) ([]Exchange, error) {
+	var exchanges []Exchange
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var ex Exchange
+		if err := json.Unmarshal(scanner.Bytes(), &ex); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		exchanges = append(exchanges, ex)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return exchanges, nil
+}
+
+// readCloser reads from a Reader and closes a Closer
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// responseRecorder is a ResponseWriter that keeps a copy of the response
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *responseRecorder) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
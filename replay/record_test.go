@@ -0,0 +1,161 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TomChv/jsonrpc2/server"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockService struct {
+	// offset is added to sums, so a server can be changed between a
+	// recording and its replay
+	offset int
+	calls  int
+}
+
+func (ms *mockService) Sum(a, b int) int {
+	return a + b + ms.offset
+}
+
+func (ms *mockService) Counter() int {
+	ms.calls++
+	return ms.calls
+}
+
+func (ms *mockService) Log(msg string) error {
+	return nil
+}
+
+func newServer(t *testing.T, offset int) *server.JsonRPC2 {
+	s := server.New(context.TODO()).SetLogger(server.NopLogger{})
+	assert.Nil(t, s.Register("mock", &mockService{offset: offset}))
+	return s
+}
+
+// record send bodies to a recorded server and return the recording
+func record(t *testing.T, s *server.JsonRPC2, bodies ...string) *bytes.Buffer {
+	var buf bytes.Buffer
+	s.Use(NewRecorder(&buf, s).SkipHeaders("X-Api-Key").Middleware)
+
+	for _, body := range bodies {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("X-Api-Key", "secret")
+		req.Header.Set("X-Request-Id", "42")
+		s.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	return &buf
+}
+
+// failingWriter is a Writer that always fails
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestRecorder(t *testing.T) {
+	buf := record(t, newServer(t, 0),
+		`{"jsonrpc": "2.0", "method": "mock_sum", "params": [1, 2], "id": 1}`,
+		`{"jsonrpc": "2.0", "method": "mock_log", "params": ["hello"]}`,
+		`{"jsonrpc": "2.0", "method": "mock_unknown", "id": 2}`,
+	)
+
+	exchanges, err := Load(buf)
+	assert.Nil(t, err)
+	assert.Len(t, exchanges, 3)
+
+	ex := exchanges[0]
+	assert.Equal(t, http.MethodPost, ex.Method)
+	assert.Equal(t, "/", ex.URL)
+	assert.Equal(t, `{"jsonrpc": "2.0", "method": "mock_sum", "params": [1, 2], "id": 1}`, ex.Request)
+	assert.Equal(t, http.StatusOK, ex.Status)
+	assert.Equal(t, `{"jsonrpc":"2.0","result":3,"id":1}`, ex.Response)
+	assert.Equal(t, "42", ex.Header.Get("X-Request-Id"))
+	assert.Equal(t, "application/json", ex.ResponseHeader.Get("Content-Type"))
+	assert.False(t, ex.Time.IsZero())
+
+	// Credentials are not recorded
+	assert.Empty(t, ex.Header.Get("Authorization"))
+	assert.Empty(t, ex.Header.Get("X-Api-Key"))
+
+	assert.Equal(t, "", exchanges[1].Response)
+	assert.Contains(t, exchanges[2].Response, `"code":-32601`)
+}
+
+func TestRecorder_Warn(t *testing.T) {
+	var warnings []error
+
+	rec := NewRecorder(failingWriter{}, nil)
+	rec.Warn = func(err error) {
+		warnings = append(warnings, err)
+	}
+
+	s := newServer(t, 0).Use(rec.Middleware)
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc": "2.0", "method": "mock_sum", "params": [1, 2], "id": 1}`))
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+
+	// The response is sent anyway
+	assert.Equal(t, `{"jsonrpc":"2.0","result":3,"id":1}`, w.Body.String())
+	assert.Len(t, warnings, 1)
+	assert.EqualError(t, warnings[0], "record exchange: disk full")
+}
+
+func TestRecorder_Redaction(t *testing.T) {
+	body := `{"jsonrpc": "2.0", "method": "mock_log", "params": ["secret"], "id": 1}`
+
+	s := newServer(t, 0)
+	assert.Nil(t, s.AddRedaction("mock_log", "params[0]"))
+	assert.Nil(t, s.AddRedaction("mock_sum", "result"))
+
+	exchanges, err := Load(record(t, s, body, `{"jsonrpc": "2.0", "method": "mock_Sum", "params": [1, 2], "id": 2}`))
+	assert.Nil(t, err)
+	assert.Len(t, exchanges, 2)
+	assert.Equal(t, `{"id":1,"jsonrpc":"2.0","method":"mock_log","params":["[REDACTED]"]}`, exchanges[0].Request)
+	assert.Equal(t, `{"id":2,"jsonrpc":"2.0","result":"[REDACTED]"}`, exchanges[1].Response)
+
+	// Redacted results match any replayed value
+	report, err := Replay(context.TODO(), Handler(newServer(t, 0)), exchanges, Options{})
+	assert.Nil(t, err)
+	assert.True(t, report.OK(), report.String())
+
+	// Params sent with GET are redacted in the URL
+	s = newServer(t, 0).SetHTTPOptions(server.HTTPOptions{GETMethods: []string{"mock_*"}})
+	assert.Nil(t, s.AddRedaction("mock_log", "params[0]"))
+	buf := record(t, s)
+	s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/?method=mock_log&params=%5B%22secret%22%5D&id=1", nil))
+
+	exchanges, err = Load(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, "/?id=1&method=mock_log&params=WyJbUkVEQUNURURdIl0%3D", exchanges[0].URL)
+
+	// Recording without redaction
+	buf = &bytes.Buffer{}
+	s = newServer(t, 0).Use(NewRecorder(buf, nil).Middleware)
+	assert.Nil(t, s.AddRedaction("mock_log", "params[0]"))
+	s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+
+	exchanges, err = Load(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, body, exchanges[0].Request)
+}
+
+func TestLoad(t *testing.T) {
+	exchanges, err := Load(strings.NewReader("{\"method\": \"POST\"}\n\n{\"method\": \"GET\"}\n"))
+	assert.Nil(t, err)
+	assert.Len(t, exchanges, 2)
+	assert.Equal(t, http.MethodGet, exchanges[1].Method)
+
+	_, err = Load(strings.NewReader("{\"method\": \"POST\"}\n{"))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "line 2")
+}
@@ -0,0 +1,314 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+
+	"github.com/TomChv/jsonrpc2/client"
+	"github.com/TomChv/jsonrpc2/redact"
+)
+
+// Target receives replayed requests
+type Target interface {
+	// Send replay the request of ex, it returns the response status and
+	// body. Status is 0 if the target does not expose it.
+	Send(ctx context.Context, ex *Exchange) (int, []byte, error)
+}
+
+// handlerTarget replays requests in-process against an http.Handler
+type handlerTarget struct {
+	h http.Handler
+}
+
+// Handler replay requests against h, such as a server.JsonRPC2, with their
+// recorded method, URL and headers.
+// Credential headers are not recorded, see DefaultSkippedHeaders, replays
+// against a server requiring authentication send them with Options.Header.
+func Handler(h http.Handler) Target {
+	return handlerTarget{h: h}
+}
+
+func (t handlerTarget) Send(ctx context.Context, ex *Exchange) (int, []byte, error) {
+	req := httptest.NewRequest(ex.Method, ex.URL, strings.NewReader(ex.Request)).WithContext(ctx)
+	for key, values := range ex.Header {
+		req.Header[key] = values
+	}
+
+	w := httptest.NewRecorder()
+	t.h.ServeHTTP(w, req)
+
+	return w.Code, w.Body.Bytes(), nil
+}
+
+// clientTarget replays requests through a client
+type clientTarget struct {
+	c *client.Client
+}
+
+// Client replay request bodies through c, the URL and headers of c are used
+// instead of the recorded ones and statuses are not compared
+func Client(c *client.Client) Target {
+	return clientTarget{c: c}
+}
+
+func (t clientTarget) Send(ctx context.Context, ex *Exchange) (int, []byte, error) {
+	body, err := t.c.Send(ctx, []byte(ex.Request))
+	return 0, body, err
+}
+
+// Options of a replay
+type Options struct {
+	// Ignore are paths of the results that are not compared, such as
+	// timestamps or generated identifiers. Paths are rooted at result, see
+	// redact.Path, they apply to each response of a batch.
+	Ignore []string
+
+	// Header is set on each replayed request, over the recorded headers,
+	// such as the credentials that are not recorded. It is not sent through
+	// a Client target, which uses its own headers.
+	Header http.Header
+}
+
+// Diff is a replayed exchange whose response changed
+type Diff struct {
+	// Index is the position of the exchange in the recording
+	Index    int
+	Exchange Exchange
+
+	// Status and Response are the replayed response
+	Status   int
+	Response string
+
+	// Err is set if the request could not be replayed
+	Err error
+}
+
+func (d Diff) String() string {
+	actual := fmt.Sprintf("%d %s", d.Status, d.Response)
+	if d.Err != nil {
+		actual = "error: " + d.Err.Error()
+	}
+
+	return fmt.Sprintf("exchange %d (%s %s)\n  request:  %s\n  expected: %d %s\n  actual:   %s",
+		d.Index, d.Exchange.Method, d.Exchange.URL, d.Exchange.Request,
+		d.Exchange.Status, d.Exchange.Response, actual)
+}
+
+// Report is the outcome of a replay
+type Report struct {
+	// Replayed is the number of replayed exchanges
+	Replayed int
+
+	// Diffs are the exchanges whose response changed, in recording order
+	Diffs []Diff
+}
+
+// OK return true if every response matched the recorded one
+func (r *Report) OK() bool {
+	return len(r.Diffs) == 0
+}
+
+func (r *Report) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%d/%d responses differ", len(r.Diffs), r.Replayed)
+	for _, d := range r.Diffs {
+		b.WriteString("\n" + d.String())
+	}
+
+	return b.String()
+}
+
+// Replay send the requests of exchanges to target in order and compare each
+// response with the recorded one.
+//
+// Responses are compared as JSON, responses of a batch may be in any order
+// and values redacted in the recording match any value. Redacted params are
+// replayed as redact.Mask, the responses depending on them are likely to
+// differ.
+// It returns an error if an ignored path is invalid.
+func Replay(ctx context.Context, target Target, exchanges []Exchange, opts Options) (*Report, error) {
+	var ignored []redact.Path
+	for _, p := range opts.Ignore {
+		parsed, err := redact.ParsePath(p)
+		if err != nil {
+			return nil, err
+		}
+		ignored = append(ignored, parsed)
+	}
+
+	report := &Report{}
+	for i := range exchanges {
+		ex := exchanges[i]
+		status, body, err := target.Send(ctx, withHeader(ex, opts.Header))
+		report.Replayed++
+
+		switch {
+		case err != nil:
+		case status != 0 && status != ex.Status:
+		case !match(ex.Response, string(body), ignored):
+		default:
+			continue
+		}
+
+		report.Diffs = append(report.Diffs, Diff{
+			Index:    i,
+			Exchange: ex,
+			Status:   status,
+			Response: string(body),
+			Err:      err,
+		})
+	}
+
+	return report, nil
+}
+
+// withHeader return a copy of ex whose headers are overridden by header
+func withHeader(ex Exchange, header http.Header) *Exchange {
+	if len(header) == 0 {
+		return &ex
+	}
+
+	h := ex.Header.Clone()
+	if h == nil {
+		h = http.Header{}
+	}
+	for key, values := range header {
+		h[http.CanonicalHeaderKey(key)] = values
+	}
+
+	ex.Header = h
+	return &ex
+}
+
+// match compare two raw responses or batch of responses
+func match(expected, actual string, ignored []redact.Path) bool {
+	expected, actual = strings.TrimSpace(expected), strings.TrimSpace(actual)
+	if expected == "" || actual == "" {
+		return expected == actual
+	}
+
+	e, err := decode(expected, ignored)
+	if err != nil {
+		return expected == actual
+	}
+	a, err := decode(actual, ignored)
+	if err != nil {
+		return false
+	}
+
+	expectedBatch, ok := e.([]interface{})
+	if !ok {
+		return equal(e, a)
+	}
+
+	actualBatch, ok := a.([]interface{})
+	if !ok || len(actualBatch) != len(expectedBatch) {
+		return false
+	}
+
+	// Responses of a batch may be in any order
+	used := make([]bool, len(actualBatch))
+	for _, e := range expectedBatch {
+		found := false
+		for i, a := range actualBatch {
+			if !used[i] && equal(e, a) {
+				used[i], found = true, true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// equal compare decoded responses, values redacted in the recording match
+// any value
+func equal(expected, actual interface{}) bool {
+	switch e := expected.(type) {
+	case string:
+		if e == redact.Mask {
+			return true
+		}
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok || len(a) != len(e) {
+			return false
+		}
+		for k, v := range e {
+			if av, ok := a[k]; !ok || !equal(v, av) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok || len(a) != len(e) {
+			return false
+		}
+		for i := range e {
+			if !equal(e[i], a[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return reflect.DeepEqual(expected, actual)
+}
+
+// decode a raw response or batch of responses, the ignored members of
+// results are masked
+func decode(data string, ignored []redact.Path) (interface{}, error) {
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if len(ignored) == 0 {
+		return v, nil
+	}
+
+	responses, ok := v.([]interface{})
+	if !ok {
+		responses = []interface{}{v}
+	}
+
+	for _, res := range responses {
+		obj, ok := res.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		result, ok := obj["result"]
+		if !ok {
+			continue
+		}
+
+		raw, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(redact.Apply(redact.RootResult, raw, ignored)))
+		dec.UseNumber()
+		if err := dec.Decode(&result); err != nil {
+			return nil, err
+		}
+		obj["result"] = result
+	}
+
+	return v, nil
+}
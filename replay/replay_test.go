@@ -0,0 +1,161 @@
+package replay
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TomChv/jsonrpc2/client"
+	"github.com/TomChv/jsonrpc2/redact"
+	"github.com/TomChv/jsonrpc2/server"
+	"github.com/stretchr/testify/assert"
+)
+
+var bodies = []string{
+	`{"jsonrpc": "2.0", "method": "mock_sum", "params": [1, 2], "id": 1}`,
+	`{"jsonrpc": "2.0", "method": "mock_log", "params": ["hello"]}`,
+	`[{"jsonrpc": "2.0", "method": "mock_sum", "params": [3, 4], "id": 2}, {"jsonrpc": "2.0", "method": "mock_unknown", "id": 3}]`,
+	`{"jsonrpc": "2.0", "method": "mock_sum", "params": [1], "id": 4}`,
+}
+
+func TestReplay(t *testing.T) {
+	exchanges, err := Load(record(t, newServer(t, 0), bodies...))
+	assert.Nil(t, err)
+
+	testCases := []struct {
+		name          string
+		target        Target
+		expectedDiffs []int
+	}{
+		{
+			name:   "Same server",
+			target: Handler(newServer(t, 0)),
+		},
+		{
+			name:          "Changed server",
+			target:        Handler(newServer(t, 1)),
+			expectedDiffs: []int{0, 2},
+		},
+		{
+			name: "Client",
+			target: func() Target {
+				ts := httptest.NewServer(newServer(t, 0))
+				t.Cleanup(ts.Close)
+				return Client(client.New(ts.URL + "/"))
+			}(),
+		},
+		{
+			name: "Client of a changed server",
+			target: func() Target {
+				ts := httptest.NewServer(newServer(t, 1))
+				t.Cleanup(ts.Close)
+				return Client(client.New(ts.URL + "/"))
+			}(),
+			expectedDiffs: []int{0, 2},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Replay(context.TODO(), tt.target, exchanges, Options{})
+			assert.Nil(t, err)
+			assert.Equal(t, len(exchanges), report.Replayed)
+			assert.Equal(t, len(tt.expectedDiffs) == 0, report.OK())
+
+			var indexes []int
+			for _, d := range report.Diffs {
+				indexes = append(indexes, d.Index)
+			}
+			assert.Equal(t, tt.expectedDiffs, indexes)
+		})
+	}
+}
+
+func TestReplay_Ignore(t *testing.T) {
+	s := newServer(t, 0)
+	exchanges, err := Load(record(t, s,
+		`{"jsonrpc": "2.0", "method": "mock_counter", "id": 1}`,
+		`[{"jsonrpc": "2.0", "method": "mock_counter", "id": 2}, {"jsonrpc": "2.0", "method": "mock_sum", "params": [1, 2], "id": 3}]`,
+	))
+	assert.Nil(t, err)
+
+	// The counter keeps increasing on the recorded server
+	report, err := Replay(context.TODO(), Handler(s), exchanges, Options{})
+	assert.Nil(t, err)
+	assert.Len(t, report.Diffs, 2)
+
+	report, err = Replay(context.TODO(), Handler(s), exchanges, Options{Ignore: []string{"result"}})
+	assert.Nil(t, err)
+	assert.True(t, report.OK(), report.String())
+
+	_, err = Replay(context.TODO(), Handler(s), exchanges, Options{Ignore: []string{"id"}})
+	assert.Equal(t, redact.ErrInvalidPath, err)
+}
+
+func TestReplay_Header(t *testing.T) {
+	exchanges, err := Load(record(t, newServer(t, 0), bodies[0]))
+	assert.Nil(t, err)
+
+	// The API key is not recorded
+	s := newServer(t, 0).SetAuthenticators(server.APIKeyAuthenticator("X-Api-Key", map[string]*server.Principal{
+		"secret": {ID: "recorder"},
+	}))
+	report, err := Replay(context.TODO(), Handler(s), exchanges, Options{})
+	assert.Nil(t, err)
+	assert.Len(t, report.Diffs, 1)
+	assert.Equal(t, http.StatusUnauthorized, report.Diffs[0].Status)
+
+	header := http.Header{}
+	header.Set("X-Api-Key", "secret")
+	report, err = Replay(context.TODO(), Handler(s), exchanges, Options{Header: header})
+	assert.Nil(t, err)
+	assert.True(t, report.OK(), report.String())
+
+	// Recorded headers are kept
+	assert.Equal(t, "42", exchanges[0].Header.Get("X-Request-Id"))
+	assert.Equal(t, "42", withHeader(exchanges[0], header).Header.Get("X-Request-Id"))
+	assert.Empty(t, exchanges[0].Header.Get("X-Api-Key"))
+}
+
+func TestReport_String(t *testing.T) {
+	exchanges, err := Load(record(t, newServer(t, 0), bodies[0]))
+	assert.Nil(t, err)
+
+	report, err := Replay(context.TODO(), Handler(newServer(t, 1)), exchanges, Options{})
+	assert.Nil(t, err)
+
+	assert.Equal(t, `1/1 responses differ
+exchange 0 (POST /)
+  request:  {"jsonrpc": "2.0", "method": "mock_sum", "params": [1, 2], "id": 1}
+  expected: 200 {"jsonrpc":"2.0","result":3,"id":1}
+  actual:   200 {"jsonrpc":"2.0","result":4,"id":1}`, report.String())
+}
+
+func TestMatch(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected string
+		actual   string
+		match    bool
+	}{
+		{name: "Both empty", expected: "", actual: " ", match: true},
+		{name: "Missing response", expected: `{"id": 1}`, actual: "", match: false},
+		{name: "Formatting", expected: `{"result": 1, "id": 1}`, actual: `{"id":1,"result":1}`, match: true},
+		{name: "Batch in another order", expected: `[{"id": 1}, {"id": 2}]`, actual: `[{"id": 2}, {"id": 1}]`, match: true},
+		{name: "Batch of another size", expected: `[{"id": 1}, {"id": 2}]`, actual: `[{"id": 1}]`, match: false},
+		{name: "Batch replaced by a response", expected: `[{"id": 1}]`, actual: `{"id": 1}`, match: false},
+		{name: "Large numbers", expected: `{"result": 12345678901234567890}`, actual: `{"result": 12345678901234567891}`, match: false},
+		{name: "Redacted value", expected: `{"result": {"token": "[REDACTED]"}}`, actual: `{"result": {"token": {"id": 1}}}`, match: true},
+		{name: "Redacted value of a missing member", expected: `{"result": {"token": "[REDACTED]"}}`, actual: `{"result": {}}`, match: false},
+		{name: "Redacted value in a batch", expected: `[{"id": 1, "result": "[REDACTED]"}, {"id": 2}]`, actual: `[{"id": 2}, {"id": 1, "result": 4}]`, match: true},
+		{name: "Invalid recorded JSON", expected: `foo`, actual: `foo`, match: true},
+		{name: "Invalid replayed JSON", expected: `{"id": 1}`, actual: `{`, match: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.match, match(tt.expected, tt.actual, nil))
+		})
+	}
+}
@@ -0,0 +1,28 @@
+// Package replaytest runs recordings of the replay package as tests :
+//
+//	exchanges, _ := replay.Load(f)
+//	replaytest.Run(t, replay.Handler(s), exchanges, replay.Options{})
+package replaytest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/TomChv/jsonrpc2/replay"
+)
+
+// Run replay exchanges against target and reports each changed response as
+// a test error
+func Run(tb testing.TB, target replay.Target, exchanges []replay.Exchange, opts replay.Options) {
+	tb.Helper()
+
+	report, err := replay.Replay(context.Background(), target, exchanges, opts)
+	if err != nil {
+		tb.Fatal(err)
+		return
+	}
+
+	for _, d := range report.Diffs {
+		tb.Error(d.String())
+	}
+}
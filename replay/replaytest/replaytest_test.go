@@ -0,0 +1,75 @@
+package replaytest
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TomChv/jsonrpc2/replay"
+	"github.com/TomChv/jsonrpc2/server"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockService struct {
+	offset int
+}
+
+func (ms *mockService) Sum(a, b int) int {
+	return a + b + ms.offset
+}
+
+// fakeTB records the failures of a test
+type fakeTB struct {
+	testing.TB
+	errors []string
+	fatal  bool
+}
+
+func (tb *fakeTB) Helper() {}
+
+func (tb *fakeTB) Error(args ...interface{}) {
+	tb.errors = append(tb.errors, args[0].(string))
+}
+
+func (tb *fakeTB) Fatal(args ...interface{}) {
+	tb.fatal = true
+}
+
+func newServer(t *testing.T, offset int) *server.JsonRPC2 {
+	s := server.New(context.TODO()).SetLogger(server.NopLogger{})
+	assert.Nil(t, s.Register("mock", &mockService{offset: offset}))
+	return s
+}
+
+func TestRun(t *testing.T) {
+	var buf bytes.Buffer
+	s := newServer(t, 0)
+	s.Use(replay.NewRecorder(&buf, s).Middleware)
+
+	for _, body := range []string{
+		`{"jsonrpc": "2.0", "method": "mock_sum", "params": [1, 2], "id": 1}`,
+		`{"jsonrpc": "2.0", "method": "mock_sum", "params": [3, 4], "id": 2}`,
+	} {
+		s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	}
+
+	exchanges, err := replay.Load(&buf)
+	assert.Nil(t, err)
+
+	Run(t, replay.Handler(newServer(t, 0)), exchanges, replay.Options{})
+
+	// Changed responses are reported
+	tb := &fakeTB{}
+	Run(tb, replay.Handler(newServer(t, 1)), exchanges, replay.Options{})
+	assert.Len(t, tb.errors, 2)
+	assert.True(t, strings.HasPrefix(tb.errors[0], "exchange 0 (POST /)"))
+	assert.False(t, tb.fatal)
+
+	// Invalid options fail the test
+	tb = &fakeTB{}
+	Run(tb, replay.Handler(newServer(t, 0)), exchanges, replay.Options{Ignore: []string{"id"}})
+	assert.True(t, tb.fatal)
+}
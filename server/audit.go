@@ -0,0 +1,178 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/TomChv/jsonrpc2/common"
+	"github.com/TomChv/jsonrpc2/server/registry"
+)
+
+// Auditor may be implemented by a service to mark its audited methods, see
+// SetAuditSink
+type Auditor = registry.Auditor
+
+// Outcomes of audited calls
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// AuditRecord describes an audited call
+type AuditRecord struct {
+	// Time is when the call started
+	Time time.Time `json:"time"`
+
+	// Duration is the time taken to handle the call
+	Duration time.Duration `json:"duration"`
+
+	// Principal is the ID of the caller, empty if anonymous
+	Principal string `json:"principal,omitempty"`
+
+	// Remote is the network address of the caller
+	Remote string `json:"remote,omitempty"`
+
+	Method string           `json:"method"`
+	ID     common.RequestID `json:"id,omitempty"`
+
+	// Params are redacted, see AddRedaction
+	Params json.RawMessage `json:"params,omitempty"`
+
+	// Outcome is AuditSuccess or AuditFailure, failures include the error
+	// of the response
+	Outcome string           `json:"outcome"`
+	Error   *common.RpcError `json:"error,omitempty"`
+}
+
+// AuditSink stores audit records.
+// Implementations must be safe for concurrent use.
+type AuditSink interface {
+	Write(rec *AuditRecord) error
+}
+
+// SetAuditSink record the calls of audited methods into sink.
+//
+// Methods are audited if their service implements Auditor. Each call is
+// recorded once handled, including denied calls, calls that timed out and
+// calls whose procedure panicked. Failures to write a record are logged.
+func (s *JsonRPC2) SetAuditSink(sink AuditSink) *JsonRPC2 {
+	s.auditSink = sink
+	return s
+}
+
+// audited return true if c calls an audited procedure and the server has an
+// audit sink
+func (s *JsonRPC2) audited(c *call) bool {
+	return s.auditSink != nil && c.procedure != nil && c.procedure.Audited
+}
+
+// audit write the record of a handled call to the audit sink.
+// The record holds the name of the procedure as registered, whatever the
+// casing sent by the client.
+func (s *JsonRPC2) audit(ctx context.Context, c *call, res *Response, start time.Time) {
+	rec := &AuditRecord{
+		Time:     start,
+		Duration: time.Since(start),
+		Method:   c.name,
+		Outcome:  AuditSuccess,
+	}
+
+	if p := PrincipalFromContext(ctx); p != nil {
+		rec.Principal = p.ID
+	}
+	if r := HTTPRequestFromContext(ctx); r != nil {
+		rec.Remote = r.RemoteAddr
+	}
+	if c.ID != common.NullID {
+		rec.ID = c.ID
+	}
+	if params, _ := s.redactCall(c, nil); params != "" {
+		rec.Params = json.RawMessage(params)
+	}
+	if res.Error != nil {
+		rec.Outcome = AuditFailure
+		rec.Error = res.Error
+	}
+
+	if err := s.auditSink.Write(rec); err != nil {
+		s.logger.Error("write audit record", Field{FieldMethod, c.name}, Field{FieldError, err})
+	}
+}
+
+// JSONLinesAuditSink writes each record as a line of JSON
+type JSONLinesAuditSink struct {
+	w io.Writer
+	l sync.Mutex
+}
+
+// NewJSONLinesAuditSink create a sink writing records to w
+func NewJSONLinesAuditSink(w io.Writer) *JSONLinesAuditSink {
+	return &JSONLinesAuditSink{w: w}
+}
+
+func (s *JSONLinesAuditSink) Write(rec *AuditRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	// A record is written with a single call so lines are never interleaved
+	_, err = s.w.Write(append(data, '\n'))
+	return err
+}
+
+// FileAuditSink appends records as JSON lines to a file
+type FileAuditSink struct {
+	*JSONLinesAuditSink
+	f *os.File
+}
+
+// NewFileAuditSink open the file at path in append-only mode, it is created
+// if it does not exist
+func NewFileAuditSink(path string) (*FileAuditSink, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileAuditSink{JSONLinesAuditSink: NewJSONLinesAuditSink(f), f: f}, nil
+}
+
+// Close the file, records can not be written anymore
+func (s *FileAuditSink) Close() error {
+	return s.f.Close()
+}
+
+// MemoryAuditSink keeps records in memory, it is meant for tests
+type MemoryAuditSink struct {
+	records []AuditRecord
+	l       sync.Mutex
+}
+
+// NewMemoryAuditSink create an empty MemoryAuditSink
+func NewMemoryAuditSink() *MemoryAuditSink {
+	return &MemoryAuditSink{}
+}
+
+func (s *MemoryAuditSink) Write(rec *AuditRecord) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.records = append(s.records, *rec)
+	return nil
+}
+
+// Records return a copy of the records in write order
+func (s *MemoryAuditSink) Records() []AuditRecord {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	return append([]AuditRecord(nil), s.records...)
+}
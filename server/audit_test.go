@@ -0,0 +1,248 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockTransfer struct {
+	To     string `json:"to"`
+	Amount int    `json:"amount"`
+	PIN    string `json:"pin" rpc:"secret"`
+}
+
+type mockBankService struct {
+	unblock chan struct{}
+}
+
+func (ms *mockBankService) Transfer(t mockTransfer) (int, error) {
+	return t.Amount, nil
+}
+
+func (ms *mockBankService) Balance() int {
+	return 42
+}
+
+func (ms *mockBankService) Crash() (int, error) {
+	panic("boom")
+}
+
+func (ms *mockBankService) Block() error {
+	<-ms.unblock
+	return nil
+}
+
+func (ms *mockBankService) Audited(method string) bool {
+	return method != "Balance"
+}
+
+// failingAuditSink is an AuditSink that can not write records
+type failingAuditSink struct{}
+
+func (failingAuditSink) Write(*AuditRecord) error {
+	return errors.New("disk full")
+}
+
+func newAuditServer(t *testing.T, sink AuditSink) *JsonRPC2 {
+	service := &mockBankService{unblock: make(chan struct{})}
+	t.Cleanup(func() { close(service.unblock) })
+
+	s := New(context.TODO()).SetAuditSink(sink).SetLogger(NopLogger{})
+	s.SetAuthenticators(AuthenticatorFunc(func(r *http.Request, body []byte) (*Principal, error) {
+		if r.Header.Get("X-User") == "" {
+			return nil, nil
+		}
+		return &Principal{ID: r.Header.Get("X-User"), Roles: r.Header.Values("X-Role")}, nil
+	}))
+	assert.Nil(t, s.Register("bank", service))
+	assert.Nil(t, s.SetMethodTimeout("bank_block", 10*time.Millisecond))
+	assert.Nil(t, s.AddPolicy(Policy{Pattern: "bank_transfer", Roles: []string{"teller"}}))
+
+	return s
+}
+
+func TestJsonRPC2_Audit(t *testing.T) {
+	testCases := []struct {
+		name     string
+		user     string
+		body     string
+		expected []AuditRecord
+	}{
+		{
+			name: "Method not audited",
+			body: `{"jsonrpc": "2.0", "method": "bank_balance", "id": 1}`,
+		},
+		{
+			name: "Unknown method",
+			body: `{"jsonrpc": "2.0", "method": "bank_unknown", "id": 1}`,
+		},
+		{
+			name: "Denied call",
+			user: "bob",
+			body: `{"jsonrpc": "2.0", "method": "bank_transfer", "params": {"to": "alice", "amount": 10, "pin": "1234"}, "id": 1}`,
+			expected: []AuditRecord{{
+				Principal: "bob",
+				Method:    "bank_transfer",
+				ID:        1,
+				Params:    json.RawMessage(`{"amount":10,"pin":"[REDACTED]","to":"alice"}`),
+				Outcome:   AuditFailure,
+				Error:     ForbiddenError(ErrMissingRole),
+			}},
+		},
+		{
+			name: "Panic",
+			body: `{"jsonrpc": "2.0", "method": "bank_crash", "id": "a"}`,
+			expected: []AuditRecord{{
+				Method:  "bank_crash",
+				ID:      "a",
+				Outcome: AuditFailure,
+				Error:   InternalError(ErrProcedurePanic),
+			}},
+		},
+		{
+			name: "Method casing",
+			body: `{"jsonrpc": "2.0", "method": "bank_Crash", "id": 1}`,
+			expected: []AuditRecord{{
+				Method:  "bank_crash",
+				ID:      1,
+				Outcome: AuditFailure,
+				Error:   InternalError(ErrProcedurePanic),
+			}},
+		},
+		{
+			name: "Timeout of a notification",
+			body: `{"jsonrpc": "2.0", "method": "bank_block"}`,
+			expected: []AuditRecord{{
+				Method:  "bank_block",
+				Outcome: AuditFailure,
+				Error:   TimeoutError(context.DeadlineExceeded),
+			}},
+		},
+		{
+			name: "Batch",
+			body: `[
+				{"jsonrpc": "2.0", "method": "bank_balance", "id": 1},
+				{"jsonrpc": "2.0", "method": "bank_crash", "id": null}
+			]`,
+			expected: []AuditRecord{{
+				Method:  "bank_crash",
+				Outcome: AuditFailure,
+				Error:   InternalError(ErrProcedurePanic),
+			}},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := NewMemoryAuditSink()
+			s := newAuditServer(t, sink)
+
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(tt.body)))
+			if tt.user != "" {
+				req.Header.Set("X-User", tt.user)
+			}
+			s.ServeHTTP(httptest.NewRecorder(), req)
+
+			records := sink.Records()
+			assert.Equal(t, len(tt.expected), len(records))
+			for i, rec := range records {
+				if i >= len(tt.expected) {
+					break
+				}
+				assert.WithinDuration(t, time.Now(), rec.Time, time.Second)
+				assert.Equal(t, "192.0.2.1:1234", rec.Remote)
+
+				rec.Time, rec.Duration, rec.Remote = time.Time{}, 0, ""
+				assert.Equal(t, tt.expected[i], rec)
+			}
+		})
+	}
+}
+
+func TestJsonRPC2_AuditSuccess(t *testing.T) {
+	sink := NewMemoryAuditSink()
+	s := newAuditServer(t, sink)
+
+	body := `{"jsonrpc": "2.0", "method": "bank_transfer", "params": [{"to": "alice", "amount": 10, "pin": "1234"}], "id": 1}`
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(body)))
+	req.Header.Set("X-User", "bob")
+	req.Header.Set("X-Role", "teller")
+	s.ServeHTTP(httptest.NewRecorder(), req)
+
+	records := sink.Records()
+	assert.Len(t, records, 1)
+	assert.Equal(t, "bob", records[0].Principal)
+	assert.Equal(t, AuditSuccess, records[0].Outcome)
+	assert.Nil(t, records[0].Error)
+	assert.Equal(t, `[{"amount":10,"pin":"[REDACTED]","to":"alice"}]`, string(records[0].Params))
+}
+
+func TestJsonRPC2_AuditSinkError(t *testing.T) {
+	l := &mockLogger{}
+	s := newAuditServer(t, failingAuditSink{}).SetLogger(l)
+
+	body := `{"jsonrpc": "2.0", "method": "bank_crash", "id": 1}`
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(body)))
+	s.ServeHTTP(httptest.NewRecorder(), req)
+
+	var messages []string
+	for _, e := range l.entries {
+		messages = append(messages, e.msg)
+	}
+	assert.Contains(t, messages, "procedure panicked")
+	assert.Contains(t, messages, "write audit record")
+}
+
+func TestFileAuditSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	for i := 0; i < 2; i++ {
+		sink, err := NewFileAuditSink(path)
+		assert.Nil(t, err)
+
+		assert.Nil(t, sink.Write(&AuditRecord{Method: "bank_transfer", ID: i, Outcome: AuditSuccess}))
+		assert.Nil(t, sink.Close())
+	}
+
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Len(t, lines, 2)
+	for i, line := range lines {
+		var rec AuditRecord
+		assert.Nil(t, json.Unmarshal([]byte(line), &rec))
+		assert.Equal(t, "bank_transfer", rec.Method)
+		assert.Equal(t, float64(i), rec.ID)
+	}
+
+	_, err = NewFileAuditSink(filepath.Join(t.TempDir(), "missing", "audit.log"))
+	assert.NotNil(t, err)
+}
+
+func TestJSONLinesAuditSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewJSONLinesAuditSink(&buf)
+
+	rec := &AuditRecord{
+		Time:    time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC),
+		Method:  "bank_transfer",
+		Params:  json.RawMessage(`{"to":"alice"}`),
+		Outcome: AuditFailure,
+		Error:   ForbiddenError(ErrMissingRole),
+	}
+	assert.Nil(t, sink.Write(rec))
+
+	assert.Equal(t, `{"time":"2022-01-02T03:04:05Z","duration":0,"method":"bank_transfer","params":{"to":"alice"},"outcome":"failure","error":{"code":-32002,"message":"Forbidden","data":"`+ErrMissingRole.Error()+`"}}`+"\n", buf.String())
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrNoCredentials      = errors.New("no credentials found")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Principal is an authenticated caller
+type Principal struct {
+	// ID identifies the caller
+	ID string
+
+	// Roles and Scopes granted to the caller
+	Roles  []string
+	Scopes []string
+}
+
+// Authenticator identifies the caller of a HTTP request before its calls are
+// dispatched.
+// It returns ErrNoCredentials if the request does not hold the credentials it
+// expects, so the next authenticator can be tried. A nil principal without
+// error accepts the request from an anonymous caller.
+type Authenticator interface {
+	Authenticate(r *http.Request, body []byte) (*Principal, error)
+}
+
+// Challenger may be implemented by an Authenticator to tell clients how to
+// authenticate, its challenge is sent in the WWW-Authenticate header of
+// rejected requests
+type Challenger interface {
+	Challenge() string
+}
+
+// AuthenticatorFunc is an adapter to use ordinary functions as Authenticator
+type AuthenticatorFunc func(r *http.Request, body []byte) (*Principal, error)
+
+// Authenticate call f(r, body)
+func (f AuthenticatorFunc) Authenticate(r *http.Request, body []byte) (*Principal, error) {
+	return f(r, body)
+}
+
+// SetAuthenticators require every request to be authenticated by one of
+// authenticators, they are tried in order.
+// Rejected requests get an Unauthorized error with HTTP status 401 and the
+// challenges of the authenticators implementing Challenger. Errors other
+// than the ones of this package are logged and sent as
+// ErrInvalidCredentials, they may describe the credentials.
+func (s *JsonRPC2) SetAuthenticators(authenticators ...Authenticator) *JsonRPC2 {
+	s.authenticators = authenticators
+	return s
+}
+
+// PrincipalFromContext return the authenticated caller, nil if the server
+// does not authenticate requests
+func PrincipalFromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey).(*Principal)
+	return p
+}
+
+// authenticate run authenticators on the request and add the principal to
+// ctx.
+// On failure, it replies to the request and returns false.
+func (s *JsonRPC2) authenticate(ctx context.Context, w http.ResponseWriter, r *http.Request, body []byte) (context.Context, bool) {
+	if len(s.authenticators) == 0 {
+		return ctx, true
+	}
+
+	err := ErrNoCredentials
+	for _, a := range s.authenticators {
+		var p *Principal
+		p, err = a.Authenticate(r, body)
+		if err == nil {
+			return context.WithValue(ctx, principalKey, p), true
+		}
+		if !errors.Is(err, ErrNoCredentials) {
+			break
+		}
+	}
+
+	if !isAuthError(err) {
+		s.logger.Warn("authentication failed", Field{FieldError, err}, Field{FieldRemote, r.RemoteAddr})
+		err = ErrInvalidCredentials
+	}
+
+	for _, a := range s.authenticators {
+		if c, ok := a.(Challenger); ok {
+			w.Header().Add("WWW-Authenticate", c.Challenge())
+		}
+	}
+
+	s.replyError(w, r, UnauthorizedError(err))
+	return ctx, false
+}
+
+// isAuthError return true if err is an error of the built-in authenticators
+func isAuthError(err error) bool {
+	for _, e := range []error{ErrNoCredentials, ErrInvalidCredentials, ErrExpiredSignature, ErrReplayedRequest} {
+		if errors.Is(err, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// challengeAuthenticator is an AuthenticatorFunc with a challenge
+type challengeAuthenticator struct {
+	AuthenticatorFunc
+	challenge string
+}
+
+func (a challengeAuthenticator) Challenge() string {
+	return a.challenge
+}
+
+// APIKeyAuthenticator authenticate requests that hold one of keys in header
+func APIKeyAuthenticator(header string, keys map[string]*Principal) Authenticator {
+	// Keys are compared by their digest, which have the same length, so the
+	// comparison time does not leak the length of the keys
+	type entry struct {
+		digest    [sha256.Size]byte
+		principal *Principal
+	}
+
+	entries := make([]entry, 0, len(keys))
+	for k, p := range keys {
+		entries = append(entries, entry{digest: sha256.Sum256([]byte(k)), principal: p})
+	}
+
+	authenticate := func(r *http.Request, _ []byte) (*Principal, error) {
+		key := r.Header.Get(header)
+		if key == "" {
+			return nil, ErrNoCredentials
+		}
+		digest := sha256.Sum256([]byte(key))
+
+		// Every key is compared to not leak which one is close
+		var principal *Principal
+		for _, e := range entries {
+			if subtle.ConstantTimeCompare(e.digest[:], digest[:]) == 1 {
+				principal = e.principal
+			}
+		}
+
+		if principal == nil {
+			return nil, ErrInvalidCredentials
+		}
+		return principal, nil
+	}
+
+	return challengeAuthenticator{AuthenticatorFunc: authenticate, challenge: `APIKey header="` + header + `"`}
+}
+
+// BearerAuthenticator authenticate requests with a bearer token in their
+// Authorization header, the token is checked by verify
+func BearerAuthenticator(verify func(ctx context.Context, token string) (*Principal, error)) Authenticator {
+	authenticate := func(r *http.Request, _ []byte) (*Principal, error) {
+		const prefix = "bearer "
+
+		auth := r.Header.Get("Authorization")
+		if len(auth) <= len(prefix) || !strings.EqualFold(auth[:len(prefix)], prefix) {
+			return nil, ErrNoCredentials
+		}
+
+		p, err := verify(r.Context(), strings.TrimSpace(auth[len(prefix):]))
+		if err != nil {
+			return nil, err
+		}
+		if p == nil {
+			return nil, ErrInvalidCredentials
+		}
+		return p, nil
+	}
+
+	return challengeAuthenticator{AuthenticatorFunc: authenticate, challenge: "Bearer"}
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAuthService struct{}
+
+func (ms *mockAuthService) Whoami(ctx context.Context) string {
+	return PrincipalFromContext(ctx).ID
+}
+
+func TestJsonRPC2_ServeHTTP_Authenticators(t *testing.T) {
+	errExpired := errors.New("token has expired")
+
+	l := &mockLogger{}
+	s := New(context.TODO()).SetLogger(l).SetAuthenticators(
+		APIKeyAuthenticator("X-Api-Key", map[string]*Principal{
+			"secret-key": {ID: "api-user"},
+		}),
+		BearerAuthenticator(func(ctx context.Context, token string) (*Principal, error) {
+			switch token {
+			case "valid-token":
+				return &Principal{ID: "bearer-user"}, nil
+			case "expired-token":
+				return nil, errExpired
+			default:
+				return nil, nil
+			}
+		}),
+	)
+	assert.Nil(t, s.Register("auth", &mockAuthService{}))
+
+	testCases := []struct {
+		name             string
+		headers          map[string]string
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{
+			name:             "API key",
+			headers:          map[string]string{"X-Api-Key": "secret-key"},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"jsonrpc":"2.0","result":"api-user","id":1}`,
+		},
+		{
+			name:             "Invalid API key",
+			headers:          map[string]string{"X-Api-Key": "secret", "Authorization": "Bearer valid-token"},
+			expectedStatus:   http.StatusUnauthorized,
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32001,"message":"Unauthorized","data":"invalid credentials"},"id":null}`,
+		},
+		{
+			name:             "Bearer token",
+			headers:          map[string]string{"Authorization": "Bearer valid-token"},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"jsonrpc":"2.0","result":"bearer-user","id":1}`,
+		},
+		{
+			name:             "Lower case bearer token",
+			headers:          map[string]string{"Authorization": "bearer valid-token"},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"jsonrpc":"2.0","result":"bearer-user","id":1}`,
+		},
+		{
+			name:             "Rejected bearer token",
+			headers:          map[string]string{"Authorization": "Bearer expired-token"},
+			expectedStatus:   http.StatusUnauthorized,
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32001,"message":"Unauthorized","data":"invalid credentials"},"id":null}`,
+		},
+		{
+			name:             "Unknown bearer token",
+			headers:          map[string]string{"Authorization": "Bearer foo"},
+			expectedStatus:   http.StatusUnauthorized,
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32001,"message":"Unauthorized","data":"invalid credentials"},"id":null}`,
+		},
+		{
+			name:             "Basic authorization",
+			headers:          map[string]string{"Authorization": "Basic Zm9vOmJhcg=="},
+			expectedStatus:   http.StatusUnauthorized,
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32001,"message":"Unauthorized","data":"no credentials found"},"id":null}`,
+		},
+		{
+			name:             "No credentials",
+			expectedStatus:   http.StatusUnauthorized,
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32001,"message":"Unauthorized","data":"no credentials found"},"id":null}`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{"jsonrpc": "2.0", "method": "auth_whoami", "id": 1}`)))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+
+			s.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedResponse, w.Body.String())
+			if tt.expectedStatus == http.StatusUnauthorized {
+				assert.Equal(t, []string{`APIKey header="X-Api-Key"`, "Bearer"}, w.Header().Values("WWW-Authenticate"))
+			} else {
+				assert.Empty(t, w.Header().Values("WWW-Authenticate"))
+			}
+		})
+	}
+
+	// Errors of the verifier are only logged
+	var logged []interface{}
+	for _, e := range l.entries {
+		if e.msg == "authentication failed" {
+			logged = append(logged, e.fields[FieldError])
+		}
+	}
+	assert.Equal(t, []interface{}{errExpired}, logged)
+}
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	a := APIKeyAuthenticator("X-Api-Key", map[string]*Principal{
+		"secret-key": {ID: "api-user"},
+	})
+
+	for key, expectedErr := range map[string]error{
+		"":                ErrNoCredentials,
+		"secret":          ErrInvalidCredentials,
+		"secret-key-long": ErrInvalidCredentials,
+		"secret-key":      nil,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("X-Api-Key", key)
+
+		_, err := a.Authenticate(req, nil)
+		assert.Equal(t, expectedErr, err, key)
+	}
+}
+
+func TestPrincipalFromContext(t *testing.T) {
+	assert.Nil(t, PrincipalFromContext(context.TODO()))
+
+	p := &Principal{ID: "john"}
+	assert.Equal(t, p, PrincipalFromContext(context.WithValue(context.TODO(), principalKey, p)))
+}
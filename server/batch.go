@@ -1,11 +1,35 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"runtime"
 	"sync"
+
+	"github.com/TomChv/jsonrpc2/server/validator"
+)
+
+// BatchMode defines how the calls of a batch request are executed
+type BatchMode int
+
+const (
+	// BatchConcurrent runs calls concurrently, responses are sent in
+	// completion order
+	BatchConcurrent BatchMode = iota
+
+	// BatchOrdered runs calls concurrently, responses are sent in request
+	// order
+	BatchOrdered
+
+	// BatchSequential runs calls one after another in request order
+	BatchSequential
 )
 
+// UnboundedBatchWorkers executes every call of a batch at once, see
+// SetBatchWorkers
+const UnboundedBatchWorkers = -1
+
 type Batch struct {
 	responses []*Response
 	l         sync.Mutex
@@ -28,9 +52,109 @@ func (b *Batch) Send(w http.ResponseWriter) error {
 		return err
 	}
 
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", validator.ContentTypeJSON)
+	}
+
 	_, err = w.Write(data)
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+// SetBatchMode set how calls of batch requests are executed.
+// Default is BatchConcurrent.
+func (s *JsonRPC2) SetBatchMode(mode BatchMode) *JsonRPC2 {
+	s.batchMode = mode
+	return s
+}
+
+// SetBatchWorkers limit the number of calls of a batch request executed
+// concurrently.
+// If workers is 0, the default, at most runtime.GOMAXPROCS(0) calls are
+// executed at once. Use UnboundedBatchWorkers to execute every call of a
+// batch at once.
+func (s *JsonRPC2) SetBatchWorkers(workers int) *JsonRPC2 {
+	s.batchWorkers = workers
+	return s
+}
+
+// handleBatch execute each raw request of a batch with a pool of workers and
+// gather their responses according to the batch mode.
+// Response metadata of the calls are merged in request order, whatever the
+// batch mode.
+func (s *JsonRPC2) handleBatch(ctx context.Context, reqs []json.RawMessage) (*Batch, Metadata) {
+	batch := &Batch{}
+	ordered := s.batchMode != BatchConcurrent
+	mds := make([]Metadata, len(reqs))
+
+	// Ordered responses are stored at their request index
+	var slots []*Response
+	if ordered {
+		slots = make([]*Response, len(reqs))
+	}
+
+	run := func(i int) {
+		res, md := s.handleCall(ctx, reqs[i])
+		mds[i] = md
+
+		switch {
+		case res == nil:
+			// Notifications have no response
+		case ordered:
+			slots[i] = res
+		default:
+			batch.Append(res)
+		}
+	}
+
+	workers := s.batchWorkers
+	switch {
+	case s.batchMode == BatchSequential:
+		workers = 1
+	case workers == 0:
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers < 0 || workers > len(reqs) {
+		workers = len(reqs)
+	}
+
+	if workers == 1 {
+		for i := range reqs {
+			run(i)
+		}
+	} else {
+		jobs := make(chan int)
+
+		var wg sync.WaitGroup
+		wg.Add(workers)
+		for w := 0; w < workers; w++ {
+			go func() {
+				defer wg.Done()
+				for i := range jobs {
+					run(i)
+				}
+			}()
+		}
+
+		for i := range reqs {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+	}
+
+	for _, res := range slots {
+		if res != nil {
+			batch.Append(res)
+		}
+	}
+
+	md := Metadata{}
+	for _, m := range mds {
+		md.merge(m)
+	}
+
+	return batch, md
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockBatchService struct {
+	running    int64
+	maxRunning int64
+
+	calls []int
+	l     sync.Mutex
+}
+
+// Call sleeps longer for lower values so concurrent calls complete in
+// reverse order
+func (ms *mockBatchService) Call(i int) (int, error) {
+	running := atomic.AddInt64(&ms.running, 1)
+	defer atomic.AddInt64(&ms.running, -1)
+
+	for {
+		max := atomic.LoadInt64(&ms.maxRunning)
+		if running <= max || atomic.CompareAndSwapInt64(&ms.maxRunning, max, running) {
+			break
+		}
+	}
+
+	time.Sleep(time.Duration(10-i) * 5 * time.Millisecond)
+
+	ms.l.Lock()
+	ms.calls = append(ms.calls, i)
+	ms.l.Unlock()
+
+	return i, nil
+}
+
+func TestJsonRPC2_ServeHTTP_BatchMode(t *testing.T) {
+	var reqs []string
+	for i := 0; i < 10; i++ {
+		reqs = append(reqs, fmt.Sprintf(`{"jsonrpc": "2.0", "method": "batch_call", "params": [%d], "id": %d}`, i, i))
+	}
+	// Notification have no response
+	reqs = append(reqs, `{"jsonrpc": "2.0", "method": "batch_call", "params": [9]}`)
+	body := []byte("[" + strings.Join(reqs, ",") + "]")
+
+	// By default, batches run at most GOMAXPROCS calls at once
+	procs := int64(runtime.GOMAXPROCS(0))
+	if procs > 11 {
+		procs = 11
+	}
+
+	ordered := []interface{}{float64(0), float64(1), float64(2), float64(3), float64(4), float64(5), float64(6), float64(7), float64(8), float64(9)}
+
+	testCases := []struct {
+		name               string
+		mode               BatchMode
+		workers            int
+		expectedMaxRunning int64
+		expectedCalls      []int
+		expectedOrder      []interface{}
+	}{
+		{
+			name:               "Concurrent",
+			mode:               BatchConcurrent,
+			expectedMaxRunning: procs,
+		},
+		{
+			name:               "Concurrent unbounded",
+			mode:               BatchConcurrent,
+			workers:            UnboundedBatchWorkers,
+			expectedMaxRunning: 11,
+		},
+		{
+			name:               "Concurrent with workers",
+			mode:               BatchConcurrent,
+			workers:            3,
+			expectedMaxRunning: 3,
+		},
+		{
+			name:               "Ordered",
+			mode:               BatchOrdered,
+			expectedMaxRunning: procs,
+			expectedOrder:      ordered,
+		},
+		{
+			name:               "Ordered with workers",
+			mode:               BatchOrdered,
+			workers:            2,
+			expectedMaxRunning: 2,
+			expectedOrder:      ordered,
+		},
+		{
+			name:               "Sequential",
+			mode:               BatchSequential,
+			workers:            4,
+			expectedMaxRunning: 1,
+			expectedCalls:      []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 9},
+			expectedOrder:      ordered,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &mockBatchService{}
+
+			s := New(context.TODO()).SetBatchMode(tt.mode).SetBatchWorkers(tt.workers)
+			assert.Nil(t, s.Register("batch", service))
+
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+
+			s.ServeHTTP(w, req)
+
+			var res []*Response
+			assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+			assert.Len(t, res, 10)
+
+			assert.LessOrEqual(t, service.maxRunning, tt.expectedMaxRunning)
+			if tt.expectedMaxRunning > 1 {
+				assert.Greater(t, service.maxRunning, int64(1))
+			}
+			if tt.expectedCalls != nil {
+				assert.Equal(t, tt.expectedCalls, service.calls)
+			}
+
+			if tt.expectedOrder != nil {
+				var order []interface{}
+				for _, r := range res {
+					order = append(order, r.ID)
+				}
+				assert.Equal(t, tt.expectedOrder, order)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"path"
+)
+
+var (
+	ErrServerOverloaded        = errors.New("server overloaded")
+	ErrInvalidConcurrencyLimit = errors.New("concurrency limit must allow at least one call")
+)
+
+// ConcurrencyLimit bounds the number of calls running at once.
+// Calls over the limit wait in a queue, bounded by the timeout of the call,
+// calls that do not fit in the queue are rejected with an Overloaded error.
+type ConcurrencyLimit struct {
+	// MaxInFlight is the maximum number of calls running at once
+	MaxInFlight int
+
+	// MaxQueue is the maximum number of calls waiting for a slot
+	MaxQueue int
+}
+
+// limiter is a semaphore with a bounded wait queue
+type limiter struct {
+	slots chan struct{}
+	queue chan struct{}
+}
+
+func newLimiter(l ConcurrencyLimit) (*limiter, error) {
+	if l.MaxInFlight <= 0 || l.MaxQueue < 0 {
+		return nil, ErrInvalidConcurrencyLimit
+	}
+
+	return &limiter{
+		slots: make(chan struct{}, l.MaxInFlight),
+		queue: make(chan struct{}, l.MaxQueue),
+	}, nil
+}
+
+// acquire take a slot, waiting in the queue if none is free.
+// It fails at once if the queue is full, or when ctx is done.
+func (l *limiter) acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	select {
+	case l.queue <- struct{}{}:
+	default:
+		return ErrServerOverloaded
+	}
+	defer func() { <-l.queue }()
+
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release free a slot
+func (l *limiter) release() {
+	<-l.slots
+}
+
+// methodLimiter binds a glob pattern to a limiter
+type methodLimiter struct {
+	pattern string
+	limiter *limiter
+}
+
+// SetConcurrencyLimit bound the number of calls running at once on the
+// server, each call of a batch counts.
+// By default, calls are not limited.
+func (s *JsonRPC2) SetConcurrencyLimit(l ConcurrencyLimit) error {
+	limiter, err := newLimiter(l)
+	if err != nil {
+		return err
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.limiter = limiter
+	return nil
+}
+
+// SetMethodConcurrencyLimit bound the number of calls of the methods matching
+// pattern running at once, matching methods share the limit.
+// Pattern uses the path.Match syntax, if several patterns match, the first
+// one is used.
+func (s *JsonRPC2) SetMethodConcurrencyLimit(pattern string, l ConcurrencyLimit) error {
+	if pattern == "" {
+		return ErrEmptyMethodName
+	}
+
+	if _, err := path.Match(pattern, ""); err != nil {
+		return ErrInvalidPattern
+	}
+
+	limiter, err := newLimiter(l)
+	if err != nil {
+		return err
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.methodLimiters = append(s.methodLimiters, methodLimiter{pattern: pattern, limiter: limiter})
+	return nil
+}
+
+// acquire take a slot of the server and method limits of c.
+// Patterns match the name of the called procedure as registered. The
+// returned function releases the slots once the call is done.
+func (s *JsonRPC2) acquire(ctx context.Context, c *call) (func(), error) {
+	// Method limit is acquired first, so calls waiting for it do not hold
+	// a slot of the server
+	s.l.RLock()
+	limiters := make([]*limiter, 0, 2)
+	for _, m := range s.methodLimiters {
+		// Pattern is validated when the limit is set
+		if ok, _ := path.Match(m.pattern, c.name); ok {
+			limiters = append(limiters, m.limiter)
+			break
+		}
+	}
+	if s.limiter != nil {
+		limiters = append(limiters, s.limiter)
+	}
+	s.l.RUnlock()
+
+	release := func() {
+		for _, l := range limiters {
+			l.release()
+		}
+	}
+
+	for i, l := range limiters {
+		if err := l.acquire(ctx); err != nil {
+			limiters = limiters[:i]
+			release()
+			return nil, err
+		}
+	}
+
+	return release, nil
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockBlockingService struct {
+	started chan struct{}
+	unblock chan struct{}
+}
+
+func (ms *mockBlockingService) Wait() string {
+	ms.started <- struct{}{}
+	<-ms.unblock
+	return "done"
+}
+
+func (ms *mockBlockingService) Ping() string {
+	return "pong"
+}
+
+func TestLimiter(t *testing.T) {
+	_, err := newLimiter(ConcurrencyLimit{MaxInFlight: 0})
+	assert.Equal(t, ErrInvalidConcurrencyLimit, err)
+	_, err = newLimiter(ConcurrencyLimit{MaxInFlight: 1, MaxQueue: -1})
+	assert.Equal(t, ErrInvalidConcurrencyLimit, err)
+
+	l, err := newLimiter(ConcurrencyLimit{MaxInFlight: 1, MaxQueue: 1})
+	assert.Nil(t, err)
+
+	assert.Nil(t, l.acquire(context.TODO()))
+
+	// Second call waits in the queue
+	acquired := make(chan error)
+	go func() {
+		acquired <- l.acquire(context.TODO())
+	}()
+	assert.Eventually(t, func() bool { return len(l.queue) == 1 }, time.Second, time.Millisecond)
+
+	// Third call is rejected
+	assert.Equal(t, ErrServerOverloaded, l.acquire(context.TODO()))
+
+	l.release()
+	assert.Nil(t, <-acquired)
+	assert.Len(t, l.queue, 0)
+
+	// Waiting calls give up when their context is done
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, l.acquire(ctx))
+	assert.Len(t, l.queue, 0)
+}
+
+func TestJsonRPC2_SetMethodConcurrencyLimit(t *testing.T) {
+	s := New(context.TODO())
+
+	assert.Nil(t, s.SetConcurrencyLimit(ConcurrencyLimit{MaxInFlight: 1}))
+	assert.Equal(t, ErrInvalidConcurrencyLimit, s.SetConcurrencyLimit(ConcurrencyLimit{}))
+	assert.Nil(t, s.SetMethodConcurrencyLimit("block_*", ConcurrencyLimit{MaxInFlight: 1}))
+	assert.Equal(t, ErrEmptyMethodName, s.SetMethodConcurrencyLimit("", ConcurrencyLimit{MaxInFlight: 1}))
+	assert.Equal(t, ErrInvalidPattern, s.SetMethodConcurrencyLimit("block_[", ConcurrencyLimit{MaxInFlight: 1}))
+	assert.Equal(t, ErrInvalidConcurrencyLimit, s.SetMethodConcurrencyLimit("block_*", ConcurrencyLimit{}))
+}
+
+func TestJsonRPC2_ServeHTTP_ConcurrencyLimit(t *testing.T) {
+	service := &mockBlockingService{started: make(chan struct{}, 2), unblock: make(chan struct{})}
+	s := New(context.TODO())
+	assert.Nil(t, s.Register("block", service))
+	assert.Nil(t, s.SetConcurrencyLimit(ConcurrencyLimit{MaxInFlight: 2}))
+	assert.Nil(t, s.SetMethodConcurrencyLimit("block_wait", ConcurrencyLimit{MaxInFlight: 1, MaxQueue: 1}))
+
+	send := func(method string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{"jsonrpc": "2.0", "method": "`+method+`", "id": 1}`)))
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		return w
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			defer wg.Done()
+			w := send("block_wait")
+			assert.Equal(t, `{"jsonrpc":"2.0","result":"done","id":1}`, w.Body.String())
+		}()
+	}
+
+	// One call runs, the other one is queued
+	<-service.started
+	assert.Eventually(t, func() bool { return len(s.methodLimiters[0].limiter.queue) == 1 }, time.Second, time.Millisecond)
+
+	// Method queue is full
+	w := send("block_wait")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, `{"jsonrpc":"2.0","error":{"code":-32005,"message":"Server overloaded","data":"server overloaded"},"id":1}`, w.Body.String())
+
+	// Method casing does not escape the limit
+	w = send("block_Wait")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	// Another method only depends on the server limit
+	w = send("block_ping")
+	assert.Equal(t, `{"jsonrpc":"2.0","result":"pong","id":1}`, w.Body.String())
+
+	service.unblock <- struct{}{}
+	<-service.started
+	service.unblock <- struct{}{}
+	wg.Wait()
+}
+
+func TestJsonRPC2_ServeHTTP_ConcurrencyLimit_Batch(t *testing.T) {
+	service := &mockBlockingService{started: make(chan struct{}, 1), unblock: make(chan struct{})}
+	s := New(context.TODO()).SetBatchMode(BatchOrdered)
+	assert.Nil(t, s.Register("block", service))
+	assert.Nil(t, s.SetConcurrencyLimit(ConcurrencyLimit{MaxInFlight: 1}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{"jsonrpc": "2.0", "method": "block_wait", "id": 1}`)))
+		s.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+	<-service.started
+
+	// Each call of a batch counts
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`[{"jsonrpc": "2.0", "method": "block_ping", "id": 1},{"jsonrpc": "2.0", "method": "block_ping", "id": 2}]`)))
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `[{"jsonrpc":"2.0","error":{"code":-32005,"message":"Server overloaded","data":"server overloaded"},"id":1},{"jsonrpc":"2.0","error":{"code":-32005,"message":"Server overloaded","data":"server overloaded"},"id":2}]`, w.Body.String())
+
+	service.unblock <- struct{}{}
+	<-done
+}
+
+func TestJsonRPC2_ServeHTTP_ConcurrencyLimit_Timeout(t *testing.T) {
+	service := &mockBlockingService{started: make(chan struct{}, 1), unblock: make(chan struct{})}
+	s := New(context.TODO())
+	assert.Nil(t, s.Register("block", service))
+	assert.Nil(t, s.SetConcurrencyLimit(ConcurrencyLimit{MaxInFlight: 1, MaxQueue: 1}))
+	assert.Nil(t, s.SetMethodTimeout("block_ping", 10*time.Millisecond))
+
+	send := func(ctx context.Context, method string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{"jsonrpc": "2.0", "method": "`+method+`", "id": 1}`)))
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req.WithContext(ctx))
+		return w
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		send(context.TODO(), "block_wait")
+	}()
+	<-service.started
+
+	// The timeout bounds the wait in the queue
+	w := send(context.TODO(), "block_ping")
+	assert.Equal(t, `{"jsonrpc":"2.0","error":{"code":-32004,"message":"Timeout","data":"context deadline exceeded"},"id":1}`, w.Body.String())
+
+	// A client giving up is not reported as an overload
+	ctx, cancel := context.WithCancel(context.TODO())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	w = send(ctx, "block_wait")
+	assert.Equal(t, `{"jsonrpc":"2.0","error":{"code":-32006,"message":"Canceled","data":"context canceled"},"id":1}`, w.Body.String())
+
+	service.unblock <- struct{}{}
+	<-done
+}
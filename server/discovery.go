@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/TomChv/jsonrpc2/server/registry"
+)
+
+// DiscoverMethod is the method that lists the methods of the server, see
+// EnableDiscovery
+const DiscoverMethod = "rpc.discover"
+
+// Discovery is the result of DiscoverMethod
+type Discovery struct {
+	Methods []MethodDescription `json:"methods"`
+}
+
+// MethodDescription describes a method of the server
+type MethodDescription struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// EnableDiscovery answer DiscoverMethod calls with the service procedures
+// and raw handlers of the server.
+// Methods the caller is not allowed to call are not listed.
+func (s *JsonRPC2) EnableDiscovery() *JsonRPC2 {
+	s.discovery = true
+	return s
+}
+
+// discover list the methods the caller is allowed to call
+func (s *JsonRPC2) discover(ctx context.Context) *Discovery {
+	var methods []MethodDescription
+	for _, m := range s.r.Methods() {
+		methods = append(methods, MethodDescription{Name: procedureName(m), Description: m.Doc})
+	}
+
+	s.l.RLock()
+	for name := range s.handlers {
+		methods = append(methods, MethodDescription{Name: name})
+	}
+	s.l.RUnlock()
+
+	res := &Discovery{Methods: []MethodDescription{}}
+	for _, m := range methods {
+		if s.authorize(ctx, m.Name) == nil {
+			res.Methods = append(res.Methods, m)
+		}
+	}
+
+	sort.Slice(res.Methods, func(i, j int) bool {
+		return res.Methods[i].Name < res.Methods[j].Name
+	})
+
+	return res
+}
+
+// procedureName return the name a service procedure is called with
+func procedureName(m *registry.Method) string {
+	name := lowerFirst(m.Name)
+	if m.Service != "" {
+		name = m.Service + "_" + name
+	}
+	return name
+}
+
+// lowerFirst convert the name of a Go method into its RPC form
+func lowerFirst(str string) string {
+	if str == "" {
+		return ""
+	}
+	return strings.ToLower(str[:1]) + str[1:]
+}
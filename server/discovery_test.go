@@ -0,0 +1,69 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TomChv/jsonrpc2/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func (ms *mockAdminService) Describe(method string) string {
+	return method + " the admin state"
+}
+
+func TestJsonRPC2_ServeHTTP_Discovery(t *testing.T) {
+	s := newPolicyServer(t).EnableDiscovery()
+	assert.Nil(t, s.RegisterHandler("ping", HandlerFunc(func(ctx context.Context, req *common.Request) (interface{}, *common.RpcError) {
+		return "pong", nil
+	})))
+
+	testCases := []struct {
+		name             string
+		key              string
+		expectedResponse string
+	}{
+		{
+			name:             "Anonymous caller",
+			expectedResponse: `{"jsonrpc":"2.0","result":{"methods":[{"name":"ping"}]},"id":1}`,
+		},
+		{
+			name:             "Admin caller",
+			key:              "admin",
+			expectedResponse: `{"jsonrpc":"2.0","result":{"methods":[{"name":"admin_reset","description":"Reset the admin state"},{"name":"billing_invoice"},{"name":"ping"}]},"id":1}`,
+		},
+		{
+			name:             "Writer caller",
+			key:              "writer",
+			expectedResponse: `{"jsonrpc":"2.0","result":{"methods":[{"name":"billing_invoice"},{"name":"billing_refund"},{"name":"ping"}]},"id":1}`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{"jsonrpc": "2.0", "method": "rpc.discover", "id": 1}`)))
+			if tt.key != "" {
+				req.Header.Set("X-Api-Key", tt.key)
+			}
+			w := httptest.NewRecorder()
+
+			s.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedResponse, w.Body.String())
+		})
+	}
+}
+
+func TestJsonRPC2_ServeHTTP_DiscoveryDisabled(t *testing.T) {
+	s := New(context.TODO()).SetStrict(true)
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{"jsonrpc": "2.0", "method": "rpc.discover", "id": 1}`)))
+	w := httptest.NewRecorder()
+
+	s.ServeHTTP(w, req)
+
+	assert.Equal(t, `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found","data":"service is not registered"},"id":1}`, w.Body.String())
+}
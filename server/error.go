@@ -57,3 +57,78 @@ func CustomError(code int64, err error) *common.RpcError {
 		Data:    err.Error(),
 	}
 }
+
+// Implementation-defined server-errors
+const (
+	CodeUnauthorized int64 = -32001
+	CodeForbidden    int64 = -32002
+	CodeRateLimited  int64 = -32003
+	CodeTimeout      int64 = -32004
+	CodeOverloaded   int64 = -32005
+	CodeCanceled     int64 = -32006
+)
+
+// UnauthorizedError when the caller could not be authenticated
+func UnauthorizedError(err error) *common.RpcError {
+	return &common.RpcError{
+		Code:    CodeUnauthorized,
+		Message: "Unauthorized",
+		Data:    err.Error(),
+	}
+}
+
+// ForbiddenError when the caller is not allowed to call the method
+func ForbiddenError(err error) *common.RpcError {
+	return &common.RpcError{
+		Code:    CodeForbidden,
+		Message: "Forbidden",
+		Data:    err.Error(),
+	}
+}
+
+// RateLimitedData is the data of a RateLimitedError
+type RateLimitedData struct {
+	Message string `json:"message"`
+
+	// RetryAfter is the number of seconds to wait before calling again
+	RetryAfter int64 `json:"retryAfter"`
+}
+
+// RateLimitedError when the caller exceeded a rate limit
+func RateLimitedError(err error, retryAfter int64) *common.RpcError {
+	return &common.RpcError{
+		Code:    CodeRateLimited,
+		Message: "Too many requests",
+		Data: &RateLimitedData{
+			Message:    err.Error(),
+			RetryAfter: retryAfter,
+		},
+	}
+}
+
+// TimeoutError when the method did not complete before its deadline
+func TimeoutError(err error) *common.RpcError {
+	return &common.RpcError{
+		Code:    CodeTimeout,
+		Message: "Timeout",
+		Data:    err.Error(),
+	}
+}
+
+// OverloadedError when the server is too busy to handle the call
+func OverloadedError(err error) *common.RpcError {
+	return &common.RpcError{
+		Code:    CodeOverloaded,
+		Message: "Server overloaded",
+		Data:    err.Error(),
+	}
+}
+
+// CanceledError when the caller gave up on the call before it completed
+func CanceledError(err error) *common.RpcError {
+	return &common.RpcError{
+		Code:    CodeCanceled,
+		Message: "Canceled",
+		Data:    err.Error(),
+	}
+}
@@ -1,67 +1,290 @@
 package server
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"runtime/debug"
+	"time"
 
-	"github.com/PtitLuca/go-dispatcher/dispatcher"
 	"github.com/TomChv/jsonrpc2/server/parser"
+	"github.com/TomChv/jsonrpc2/server/registry"
+	"github.com/TomChv/jsonrpc2/trace"
 )
 
-var ErrNoFunctionErrorFound = errors.New("could not retrieve function error")
+var ErrProcedurePanic = errors.New("procedure panicked")
 
-// handle json RPC 2 request :
-//   - Retrieve procedure to call
-//   - Convert arguments to their type
-//   - Execute procedure
-//   - Return response
-func (s *JsonRPC2) handle(req *Request) *Response {
-	p, err := parser.Method(req.Method)
+// nullResult is sent as result of procedures that return nothing on success
+var nullResult = json.RawMessage("null")
+
+// handleMessage parse a raw request and handle it.
+// It returns nil if the request is a notification.
+func (s *JsonRPC2) handleMessage(ctx context.Context, data []byte) *Response {
+	var c *call
+	var res *Response
+
+	if s.tracer != nil {
+		var span trace.Span
+		ctx, span = s.tracer.Start(ctx, "jsonrpc.call")
+		defer func() {
+			s.endCallSpan(span, c, res)
+		}()
+	}
+
+	start := time.Now()
+	if s.metrics != nil {
+		s.metrics.begin()
+	}
+	defer func() {
+		duration := time.Since(start)
+		if s.metrics != nil {
+			s.metrics.end(s.methodLabel(c), res.Error, duration)
+		}
+		s.logCall(ctx, c, res, duration)
+	}()
+
+	req, res := s.parseMessage(data)
+	if res != nil {
+		return res
+	}
+
+	c = s.resolve(req)
+	res = s.handle(ctx, c)
+	if res.ID == nil {
+		return nil
+	}
+	return res
+}
+
+// parseMessage parse a raw request, it returns an error response if the
+// request is invalid
+func (s *JsonRPC2) parseMessage(data []byte) (*Request, *Response) {
+	parse := parser.Request
+	if s.strict {
+		parse = parser.StrictRequest
+	}
+
+	req, err := parse(data)
+	if errors.Is(err, parser.ErrInvalidJSON) {
+		return nil, NewResponse(nil).SetError(ParsingError(err))
+	}
 	if err != nil {
-		return NewResponse(req.ID).SetError(InvalidRequestError(err))
+		res := NewResponse(nil).SetError(InvalidRequestError(err))
+		if req != nil && req.ID != nil {
+			res.SetID(req.ID)
+		}
+		return nil, res
 	}
 
-	m, err := s.d.GetMethod(p.Service, p.Method)
+	return req, nil
+}
+
+// unknownMethod is the name shared by the methods that are not registered,
+// callers could otherwise use a new name on each call
+const unknownMethod = "unknown"
+
+// call is a request along with what serves it, resolved once when the
+// request is received
+type call struct {
+	*Request
+
+	// name is the name the call is dispatched under: the name of the
+	// service procedure as registered, e.g. "auth_login" for "auth_Login",
+	// or the method itself for raw handlers and routes
+	name string
+
+	// known is false if no handler, procedure or route serves the call, it
+	// is then given to the fallback if any
+	known bool
+
+	// pattern is the pattern of the route serving the call
+	pattern string
+
+	// handler is the raw handler, route or fallback serving the call
+	handler Handler
+
+	// procedure is the service procedure serving the call
+	procedure *registry.Method
+
+	// err is the error of the procedure lookup, sent if nothing serves the
+	// call
+	err *RpcError
+}
+
+// key return the name the per-method state of c is kept under: the
+// pattern of its route, its name, or unknownMethod if nothing but the
+// fallback serves it
+func (c *call) key() string {
+	switch {
+	case c.pattern != "":
+		return c.pattern
+	case c.known:
+		return c.name
+	default:
+		return unknownMethod
+	}
+}
+
+// resolve find what serves req: its raw Handler, the procedure it calls,
+// the first route matching it or the fallback.
+func (s *JsonRPC2) resolve(req *Request) *call {
+	c := &call{Request: req, name: req.Method}
+
+	if h, ok := s.handler(req.Method); ok {
+		c.handler, c.known = h, true
+		return c
+	}
+
+	m, rpcErr := s.method(req.Method)
+	if rpcErr == nil {
+		c.procedure, c.name, c.known = m, procedureName(m), true
+		return c
+	}
+	c.err = rpcErr
+
+	// Reserved methods are never given to user handlers
+	if s.checkReserved(req.Method) != nil {
+		return c
+	}
+
+	if r, ok := s.matchRoute(req.Method); ok {
+		c.handler, c.pattern, c.known = r.handler, r.pattern, true
+		return c
+	}
+
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	c.handler = s.fallback
+	return c
+}
+
+// handle json RPC 2 call :
+//   - Verify that the caller is allowed to call the method
+//   - Verify that the caller did not exceed rate limits
+//   - Wait for the concurrency limits
+//   - Dispatch the call, bound by its timeout
+//   - Record the call in the audit log if the method is audited
+func (s *JsonRPC2) handle(ctx context.Context, c *call) (res *Response) {
+	if s.audited(c) {
+		start := time.Now()
+		defer func() {
+			s.audit(ctx, c, res, start)
+		}()
+	}
+
+	if err := s.authorize(ctx, c.name); err != nil {
+		return NewResponse(c.ID).SetError(ForbiddenError(err))
+	}
+
+	if wait, ok := s.rateLimit(ctx, c); !ok {
+		return NewResponse(c.ID).SetError(RateLimitedError(ErrRateLimitExceeded, retryAfter(wait)))
+	}
+
+	if s.discovery && c.Method == DiscoverMethod {
+		return NewResponse(c.ID).SetResult(s.discover(ctx))
+	}
+
+	// The timeout bounds the wait for the concurrency limits as well
+	callCtx := ctx
+	timeout := s.callTimeout(ctx, c)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	release, err := s.acquire(callCtx, c)
+	if errors.Is(err, ErrServerOverloaded) {
+		return NewResponse(c.ID).SetError(OverloadedError(err))
+	}
 	if err != nil {
-		return NewResponse(req.ID).SetError(MethodNotFoundError(err))
+		return NewResponse(c.ID).SetError(contextError(err))
+	}
+
+	// Slots are released once the call returns, even after a timeout
+	if timeout > 0 {
+		return withTimeout(callCtx, c.Request, func(ctx context.Context) *Response {
+			defer release()
+			return s.dispatch(ctx, c)
+		})
+	}
+
+	defer release()
+	return s.dispatch(callCtx, c)
+}
+
+// dispatch json RPC 2 call :
+//   - Give request to its raw Handler, route or fallback if any
+//   - Convert arguments of the procedure to their type
+//   - Execute procedure, a panic is returned as an internal error
+//   - Return response
+func (s *JsonRPC2) dispatch(ctx context.Context, c *call) (res *Response) {
+	defer func() {
+		if v := recover(); v != nil {
+			s.logger.Error("procedure panicked",
+				Field{FieldMethod, c.Method}, Field{FieldError, v}, Field{FieldStack, string(debug.Stack())})
+			res = NewResponse(c.ID).SetError(InternalError(ErrProcedurePanic))
+		}
+	}()
+
+	if c.handler != nil {
+		return s.handleRaw(ctx, c.handler, c.Request)
 	}
 
-	args, err := parser.Arguments(m.GetArgsTypes()[1:], req.Params)
+	if c.procedure == nil {
+		return NewResponse(c.ID).SetError(c.err)
+	}
+	m := c.procedure
+
+	// Params are left raw by the parser
+	params, _ := c.Params.(json.RawMessage)
+
+	args, err := m.Decoder().Decode(params)
 	if err != nil {
-		return NewResponse(req.ID).SetError(InvalidParamsError(err))
+		return NewResponse(c.ID).SetError(InvalidParamsError(err))
 	}
 
 	// Run procedure
-	ret, err := s.d.Run(p.Service, p.Method, args...)
+	result, err := m.Unpack(m.Call(ctx, args))
 	if err != nil {
-		res := NewResponse(req.ID)
-
-		switch {
-		case errors.Is(err, dispatcher.ErrNonExistentMethod) ||
-			errors.Is(err, dispatcher.ErrNonExistentService):
-			res.SetError(MethodNotFoundError(err))
-		case errors.Is(err, dispatcher.ErrInvalidArgumentType) ||
-			errors.Is(err, dispatcher.ErrInvalidArgumentsCount):
-			res.SetError(InvalidParamsError(err))
-		default:
-			res.SetError(InternalError(err))
-		}
+		return NewResponse(c.ID).SetError(InternalError(err))
+	}
 
-		return res
+	// Result is required on success
+	if result == nil {
+		result = nullResult
 	}
 
-	// Check for error
-	if ret[1].Interface() != nil {
-		err, ok := ret[1].Interface().(error)
-		if !ok {
-			return NewResponse(req.ID).SetError(InternalError(ErrNoFunctionErrorFound))
-		}
+	// Send response
+	return NewResponse(c.ID).SetResult(result)
+}
 
-		// Send error
-		if err != nil {
-			return NewResponse(req.ID).SetError(InternalError(err))
-		}
+// method retrieve the service procedure called by method
+func (s *JsonRPC2) method(method string) (*registry.Method, *RpcError) {
+	p, err := parser.Method(method)
+	if err != nil {
+		return nil, InvalidRequestError(err)
 	}
 
-	// Send response
-	return NewResponse(req.ID).SetResult(ret[0].Interface())
+	m, err := s.r.Method(p.Service, p.Method)
+	if err != nil {
+		return nil, MethodNotFoundError(err)
+	}
+
+	return m, nil
+}
+
+// handleRaw give the request to a raw Handler and wrap its result
+func (s *JsonRPC2) handleRaw(ctx context.Context, h Handler, req *Request) *Response {
+	result, rpcErr := h.Handle(ctx, req)
+	if rpcErr != nil {
+		return NewResponse(req.ID).SetError(rpcErr)
+	}
+
+	if result == nil {
+		result = nullResult
+	}
+
+	return NewResponse(req.ID).SetResult(result)
 }
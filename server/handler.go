@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"path"
+
+	"github.com/TomChv/jsonrpc2/common"
+)
+
+var (
+	ErrEmptyMethodName = errors.New("method name must not be empty")
+	ErrInvalidPattern  = errors.New("invalid route pattern")
+	ErrNilHandler      = errors.New("handler must not be nil")
+)
+
+// route binds a glob pattern to a Handler
+type route struct {
+	pattern string
+	handler Handler
+}
+
+// Handler responds to a JSON-RPC request without reflection.
+//
+// Params of the request are left undecoded as json.RawMessage so the
+// handler has full control over their decoding.
+// If err is nil, result is sent to the client, a nil result is sent as null.
+type Handler interface {
+	Handle(ctx context.Context, req *common.Request) (result interface{}, err *common.RpcError)
+}
+
+// HandlerFunc is an adapter to use an ordinary function as a Handler
+type HandlerFunc func(ctx context.Context, req *common.Request) (interface{}, *common.RpcError)
+
+// Handle calls f(ctx, req)
+func (f HandlerFunc) Handle(ctx context.Context, req *common.Request) (interface{}, *common.RpcError) {
+	return f(ctx, req)
+}
+
+// RegisterHandler register a Handler for the given method name.
+//
+// The method name is matched as-is, it does not follow the service_method
+// convention of Register. Handlers take precedence over services
+// procedures. Registering a method twice replaces the previous handler.
+func (s *JsonRPC2) RegisterHandler(method string, h Handler) error {
+	if method == "" {
+		return ErrEmptyMethodName
+	}
+
+	if h == nil {
+		return ErrNilHandler
+	}
+
+	if err := s.checkReserved(method); err != nil {
+		return err
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.handlers[method] = h
+	return nil
+}
+
+// RegisterRoute register a Handler for every method matching pattern.
+//
+// Pattern uses the path.Match syntax, e.g. "debug_*" matches any method of
+// the debug namespace.
+// Routes are only used when no handler or service procedure is registered
+// for the exact method name. If several routes match, the first registered
+// one is used.
+func (s *JsonRPC2) RegisterRoute(pattern string, h Handler) error {
+	if pattern == "" {
+		return ErrEmptyMethodName
+	}
+
+	if h == nil {
+		return ErrNilHandler
+	}
+
+	if _, err := path.Match(pattern, ""); err != nil {
+		return ErrInvalidPattern
+	}
+
+	if err := s.checkReserved(pattern); err != nil {
+		return err
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.routes = append(s.routes, route{pattern: pattern, handler: h})
+	return nil
+}
+
+// SetFallback set the Handler called for methods that match no handler,
+// service procedure or route. Setting it twice replaces the previous
+// fallback.
+func (s *JsonRPC2) SetFallback(h Handler) error {
+	if h == nil {
+		return ErrNilHandler
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.fallback = h
+	return nil
+}
+
+// handler return the Handler registered for method if any
+func (s *JsonRPC2) handler(method string) (Handler, bool) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	h, ok := s.handlers[method]
+	return h, ok
+}
+
+// matchRoute return the first route matching method
+func (s *JsonRPC2) matchRoute(method string) (route, bool) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	for _, r := range s.routes {
+		// Pattern is validated at registration
+		if ok, _ := path.Match(r.pattern, method); ok {
+			return r, true
+		}
+	}
+
+	return route{}, false
+}
@@ -0,0 +1,212 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TomChv/jsonrpc2/common"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockHandler struct{}
+
+func (mh mockHandler) Handle(_ context.Context, req *common.Request) (interface{}, *common.RpcError) {
+	return req.Params, nil
+}
+
+func TestJsonRPC2_RegisterHandler(t *testing.T) {
+	s := New(context.TODO())
+
+	assert.Equal(t, ErrEmptyMethodName, s.RegisterHandler("", mockHandler{}))
+	assert.Equal(t, ErrNilHandler, s.RegisterHandler("echo", nil))
+	assert.Nil(t, s.RegisterHandler("echo", mockHandler{}))
+}
+
+func TestJsonRPC2_RegisterRoute(t *testing.T) {
+	s := New(context.TODO())
+
+	assert.Equal(t, ErrEmptyMethodName, s.RegisterRoute("", mockHandler{}))
+	assert.Equal(t, ErrInvalidPattern, s.RegisterRoute("debug_[", mockHandler{}))
+	assert.Equal(t, ErrNilHandler, s.RegisterRoute("debug_*", nil))
+	assert.Nil(t, s.RegisterRoute("debug_*", mockHandler{}))
+}
+
+func TestJsonRPC2_SetFallback(t *testing.T) {
+	s := New(context.TODO())
+
+	assert.Equal(t, ErrNilHandler, s.SetFallback(nil))
+	assert.Nil(t, s.SetFallback(mockHandler{}))
+}
+
+func TestJsonRPC2_resolve(t *testing.T) {
+	s := New(context.TODO())
+	assert.Nil(t, s.Register("mock", &mockService{}))
+	assert.Nil(t, s.RegisterHandler("Echo", mockHandler{}))
+	assert.Nil(t, s.RegisterRoute("debug_*", mockHandler{}))
+	assert.Nil(t, s.SetFallback(mockHandler{}))
+
+	testCases := []struct {
+		method          string
+		expectedName    string
+		expectedKnown   bool
+		expectedPattern string
+	}{
+		{method: "mock_methodEmptyArgs", expectedName: "mock_methodEmptyArgs", expectedKnown: true},
+		{method: "mock_MethodEmptyArgs", expectedName: "mock_methodEmptyArgs", expectedKnown: true},
+		{method: "Echo", expectedName: "Echo", expectedKnown: true},
+		{method: "echo", expectedName: "echo", expectedKnown: false},
+		{method: "debug_Trace", expectedName: "debug_Trace", expectedKnown: true, expectedPattern: "debug_*"},
+		{method: "mock_unknown", expectedName: "mock_unknown", expectedKnown: false},
+	}
+
+	for _, tt := range testCases {
+		c := s.resolve(&Request{Method: tt.method})
+		assert.Equal(t, tt.expectedName, c.name, tt.method)
+		assert.Equal(t, tt.expectedKnown, c.known, tt.method)
+		assert.Equal(t, tt.expectedPattern, c.pattern, tt.method)
+	}
+}
+
+func TestJsonRPC2_ServeHTTP_Handler(t *testing.T) {
+	s := New(context.TODO())
+	assert.Nil(t, s.Register("mock", &mockService{}))
+	assert.Nil(t, s.RegisterHandler("echo", mockHandler{}))
+	assert.Nil(t, s.RegisterHandler("eth_get_balance", HandlerFunc(func(_ context.Context, req *common.Request) (interface{}, *common.RpcError) {
+		raw, _ := req.Params.(json.RawMessage)
+
+		var params []string
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, InvalidParamsError(err)
+		}
+		return len(params), nil
+	})))
+	assert.Nil(t, s.RegisterHandler("fail", HandlerFunc(func(_ context.Context, _ *common.Request) (interface{}, *common.RpcError) {
+		return nil, CustomError(-32000, errors.New("failed"))
+	})))
+	assert.Nil(t, s.RegisterHandler("nothing", HandlerFunc(func(_ context.Context, _ *common.Request) (interface{}, *common.RpcError) {
+		return nil, nil
+	})))
+	assert.Nil(t, s.RegisterHandler("mock_methodEmptyArgs", HandlerFunc(func(_ context.Context, _ *common.Request) (interface{}, *common.RpcError) {
+		return "handler", nil
+	})))
+
+	testCases := []struct {
+		name             string
+		req              []byte
+		expectedResponse string
+	}{
+		{
+			name:             "Raw params",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "echo", "params": {"foo": [1, 2]}, "id": 1}`),
+			expectedResponse: `{"jsonrpc":"2.0","result":{"foo":[1,2]},"id":1}`,
+		},
+		{
+			name:             "Method name with many underscores",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "eth_get_balance", "params": ["foo", "bar"], "id": 1}`),
+			expectedResponse: `{"jsonrpc":"2.0","result":2,"id":1}`,
+		},
+		{
+			name:             "Handler error",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "fail", "id": 1}`),
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32000,"message":"Server error","data":"failed"},"id":1}`,
+		},
+		{
+			name:             "Null result",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "nothing", "id": 1}`),
+			expectedResponse: `{"jsonrpc":"2.0","result":null,"id":1}`,
+		},
+		{
+			name:             "Handler takes precedence over service",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "mock_methodEmptyArgs", "id": 1}`),
+			expectedResponse: `{"jsonrpc":"2.0","result":"handler","id":1}`,
+		},
+		{
+			name:             "Batch",
+			req:              []byte(`[{"jsonrpc": "2.0", "method": "echo", "params": [1], "id": 1}]`),
+			expectedResponse: `[{"jsonrpc":"2.0","result":[1],"id":1}]`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.req))
+			w := httptest.NewRecorder()
+
+			s.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedResponse, w.Body.String())
+		})
+	}
+}
+
+func TestJsonRPC2_ServeHTTP_Route(t *testing.T) {
+	named := func(name string) Handler {
+		return HandlerFunc(func(_ context.Context, req *common.Request) (interface{}, *common.RpcError) {
+			return name + ":" + req.Method, nil
+		})
+	}
+
+	testCases := []struct {
+		name             string
+		fallback         Handler
+		req              []byte
+		expectedResponse string
+	}{
+		{
+			name:             "Exact handler takes precedence over routes",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "debug_exact", "id": 1}`),
+			expectedResponse: `{"jsonrpc":"2.0","result":"exact:debug_exact","id":1}`,
+		},
+		{
+			name:             "Service procedure takes precedence over routes",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "debug_methodEmptyArgs", "id": 1}`),
+			expectedResponse: `{"jsonrpc":"2.0","result":"foo","id":1}`,
+		},
+		{
+			name:             "First matching route",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "debug_traceCall", "id": 1}`),
+			expectedResponse: `{"jsonrpc":"2.0","result":"trace:debug_traceCall","id":1}`,
+		},
+		{
+			name:             "Route matching invalid method format",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "debug_foo_bar", "id": 1}`),
+			expectedResponse: `{"jsonrpc":"2.0","result":"debug:debug_foo_bar","id":1}`,
+		},
+		{
+			name:             "No route nor fallback",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "eth_call", "id": 1}`),
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found","data":"service is not registered"},"id":1}`,
+		},
+		{
+			name:             "Fallback",
+			fallback:         named("fallback"),
+			req:              []byte(`{"jsonrpc": "2.0", "method": "eth_call", "id": 1}`),
+			expectedResponse: `{"jsonrpc":"2.0","result":"fallback:eth_call","id":1}`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(context.TODO())
+			assert.Nil(t, s.Register("debug", &mockService{}))
+			assert.Nil(t, s.RegisterHandler("debug_exact", named("exact")))
+			assert.Nil(t, s.RegisterRoute("debug_trace*", named("trace")))
+			assert.Nil(t, s.RegisterRoute("debug_*", named("debug")))
+			if tt.fallback != nil {
+				assert.Nil(t, s.SetFallback(tt.fallback))
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.req))
+			w := httptest.NewRecorder()
+
+			s.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedResponse, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	ErrExpiredSignature = errors.New("request signature has expired")
+	ErrReplayedRequest  = errors.New("request has already been received")
+)
+
+// Headers of HMAC-signed requests
+const (
+	HeaderHMACKey       = "X-Rpc-Key"
+	HeaderHMACTimestamp = "X-Rpc-Timestamp"
+	HeaderHMACSignature = "X-Rpc-Signature"
+)
+
+// sweepInterval is the interval between two drops of expired entries
+const sweepInterval = time.Minute
+
+// SignHMAC return the signature of a body sent at timestamp, as expected in
+// the HeaderHMACSignature header.
+// It is the hex encoded HMAC-SHA256 of "<timestamp>.<body>", timestamp being
+// a number of seconds since Unix epoch.
+func SignHMAC(secret []byte, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// HMACAuthenticator authenticate requests whose body is signed with the
+// secret of their key, see SignHMAC.
+// Signatures are only valid within Window around their timestamp and can be
+// used once, which protects against replayed requests.
+// The signed body of GET requests is their raw query.
+type HMACAuthenticator struct {
+	secrets map[string][]byte
+	window  time.Duration
+	now     func() time.Time
+
+	// seen holds the expiration time of received signatures
+	seen map[string]time.Time
+
+	// sweptAt is the last time expired signatures were dropped
+	sweptAt time.Time
+	l       sync.Mutex
+}
+
+// NewHMACAuthenticator create an HMACAuthenticator, secrets are indexed by
+// key which is used as principal ID
+func NewHMACAuthenticator(secrets map[string][]byte, window time.Duration) *HMACAuthenticator {
+	return &HMACAuthenticator{
+		secrets: secrets,
+		window:  window,
+		now:     time.Now,
+		seen:    make(map[string]time.Time),
+	}
+}
+
+// Authenticate verify the signature of the request body
+func (a *HMACAuthenticator) Authenticate(r *http.Request, body []byte) (*Principal, error) {
+	key := r.Header.Get(HeaderHMACKey)
+	signature := r.Header.Get(HeaderHMACSignature)
+	if key == "" || signature == "" {
+		return nil, ErrNoCredentials
+	}
+
+	secret, ok := a.secrets[key]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+
+	timestamp, err := strconv.ParseInt(r.Header.Get(HeaderHMACTimestamp), 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	expected := SignHMAC(secret, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return nil, ErrInvalidCredentials
+	}
+
+	now := a.now()
+	sentAt := time.Unix(timestamp, 0)
+	if sentAt.Before(now.Add(-a.window)) || sentAt.After(now.Add(a.window)) {
+		return nil, ErrExpiredSignature
+	}
+
+	if err := a.remember(signature, sentAt.Add(a.window), now); err != nil {
+		return nil, err
+	}
+
+	return &Principal{ID: key}, nil
+}
+
+// remember record a signature until it expires, it returns
+// ErrReplayedRequest if it was already recorded
+func (a *HMACAuthenticator) remember(signature string, expiration, now time.Time) error {
+	a.l.Lock()
+	defer a.l.Unlock()
+
+	a.sweep(now)
+
+	if _, ok := a.seen[signature]; ok {
+		return ErrReplayedRequest
+	}
+
+	a.seen[signature] = expiration
+	return nil
+}
+
+// sweep drop expired signatures, at most once per sweepInterval so each
+// request does not scan every signature
+func (a *HMACAuthenticator) sweep(now time.Time) {
+	if now.Sub(a.sweptAt) < sweepInterval {
+		return
+	}
+	a.sweptAt = now
+
+	for s, exp := range a.seen {
+		if exp.Before(now) {
+			delete(a.seen, s)
+		}
+	}
+}
+
+// Challenge is sent in the WWW-Authenticate header of rejected requests
+func (a *HMACAuthenticator) Challenge() string {
+	return "HMAC-SHA256"
+}
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHMACAuthenticator_Authenticate(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"jsonrpc": "2.0", "method": "auth_whoami", "id": 1}`)
+	secret := []byte("secret")
+
+	testCases := []struct {
+		name          string
+		key           string
+		timestamp     int64
+		signature     string
+		replay        bool
+		expectedID    string
+		expectedError error
+	}{
+		{
+			name:       "Valid signature",
+			key:        "client",
+			timestamp:  now.Unix(),
+			signature:  SignHMAC(secret, now.Unix(), body),
+			expectedID: "client",
+		},
+		{
+			name:       "Signature within window",
+			key:        "client",
+			timestamp:  now.Unix() - 30,
+			signature:  SignHMAC(secret, now.Unix()-30, body),
+			expectedID: "client",
+		},
+		{
+			name:          "No signature",
+			key:           "client",
+			expectedError: ErrNoCredentials,
+		},
+		{
+			name:          "Unknown key",
+			key:           "unknown",
+			timestamp:     now.Unix(),
+			signature:     SignHMAC(secret, now.Unix(), body),
+			expectedError: ErrInvalidCredentials,
+		},
+		{
+			name:          "Invalid signature",
+			key:           "client",
+			timestamp:     now.Unix(),
+			signature:     SignHMAC([]byte("other"), now.Unix(), body),
+			expectedError: ErrInvalidCredentials,
+		},
+		{
+			name:          "Tampered timestamp",
+			key:           "client",
+			timestamp:     now.Unix() + 1,
+			signature:     SignHMAC(secret, now.Unix(), body),
+			expectedError: ErrInvalidCredentials,
+		},
+		{
+			name:          "Expired signature",
+			key:           "client",
+			timestamp:     now.Unix() - 120,
+			signature:     SignHMAC(secret, now.Unix()-120, body),
+			expectedError: ErrExpiredSignature,
+		},
+		{
+			name:          "Replayed request",
+			key:           "client",
+			timestamp:     now.Unix() - 10,
+			signature:     SignHMAC(secret, now.Unix()-10, body),
+			replay:        true,
+			expectedError: ErrReplayedRequest,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewHMACAuthenticator(map[string][]byte{"client": secret}, time.Minute)
+			a.now = func() time.Time { return now }
+
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+			req.Header.Set(HeaderHMACKey, tt.key)
+			req.Header.Set(HeaderHMACTimestamp, strconv.FormatInt(tt.timestamp, 10))
+			req.Header.Set(HeaderHMACSignature, tt.signature)
+
+			if tt.replay {
+				_, err := a.Authenticate(req, body)
+				assert.Nil(t, err)
+			}
+
+			p, err := a.Authenticate(req, body)
+			assert.Equal(t, tt.expectedError, err)
+			if tt.expectedError == nil {
+				assert.Equal(t, tt.expectedID, p.ID)
+			}
+		})
+	}
+}
+
+func TestHMACAuthenticator_Expiration(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{}`)
+	secret := []byte("secret")
+
+	a := NewHMACAuthenticator(map[string][]byte{"client": secret}, time.Minute)
+	a.now = func() time.Time { return now }
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set(HeaderHMACKey, "client")
+	req.Header.Set(HeaderHMACTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(HeaderHMACSignature, SignHMAC(secret, now.Unix(), body))
+
+	_, err := a.Authenticate(req, body)
+	assert.Nil(t, err)
+	assert.Len(t, a.seen, 1)
+
+	// Expired signatures are forgotten
+	now = now.Add(2 * time.Minute)
+	_, err = a.Authenticate(req, body)
+	assert.Equal(t, ErrExpiredSignature, err)
+
+	req.Header.Set(HeaderHMACTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(HeaderHMACSignature, SignHMAC(secret, now.Unix(), body))
+
+	_, err = a.Authenticate(req, body)
+	assert.Nil(t, err)
+	assert.Len(t, a.seen, 1)
+	assert.Contains(t, a.seen, SignHMAC(secret, now.Unix(), body))
+}
+
+func TestJsonRPC2_ServeHTTP_HMAC(t *testing.T) {
+	secret := []byte("secret")
+	s := New(context.TODO()).
+		SetAuthenticators(NewHMACAuthenticator(map[string][]byte{"client": secret}, time.Minute)).
+		SetHTTPOptions(HTTPOptions{GETMethods: []string{"auth_*"}})
+	assert.Nil(t, s.Register("auth", &mockAuthService{}))
+
+	body := []byte(`{"jsonrpc": "2.0", "method": "auth_whoami", "id": 1}`)
+	timestamp := time.Now().Unix()
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set(HeaderHMACKey, "client")
+	req.Header.Set(HeaderHMACTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderHMACSignature, SignHMAC(secret, timestamp, body))
+	w := httptest.NewRecorder()
+
+	s.ServeHTTP(w, req)
+	assert.Equal(t, `{"jsonrpc":"2.0","result":"client","id":1}`, w.Body.String())
+
+	query := "method=auth_whoami&id=2"
+	req = httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+	req.Header.Set(HeaderHMACKey, "client")
+	req.Header.Set(HeaderHMACTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderHMACSignature, SignHMAC(secret, timestamp, []byte(query)))
+	w = httptest.NewRecorder()
+
+	s.ServeHTTP(w, req)
+	assert.Equal(t, `{"jsonrpc":"2.0","result":"client","id":2}`, w.Body.String())
+}
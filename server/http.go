@@ -0,0 +1,281 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+
+	"github.com/TomChv/jsonrpc2/common"
+	"github.com/TomChv/jsonrpc2/server/validator"
+)
+
+var (
+	ErrGETNotAllowed      = errors.New("method can not be called with GET")
+	ErrInvalidQueryParams = errors.New("params should be base64 encoded JSON")
+)
+
+// HTTPOptions enable the JSON RPC over HTTP conventions.
+// See https://www.jsonrpc.org/historical/json-rpc-over-http.html for more
+// information
+type HTTPOptions struct {
+	// StatusCodes reply to notifications with 204 No Content and map errors
+	// to HTTP status codes. Requests sent with another HTTP method than
+	// POST or GET get 405 Method Not Allowed, and requests rejected by
+	// RequireContentType get 415 Unsupported Media Type.
+	StatusCodes bool
+
+	// RequireContentType reject POST requests that are not sent as
+	// application/json
+	RequireContentType bool
+
+	// GETMethods are the patterns of methods that can be called with GET,
+	// method, params and id are then sent as query parameters. Params are
+	// base64 encoded JSON, plain JSON arrays and objects are also accepted.
+	// Patterns follow the path.Match syntax, only cacheable and read-only
+	// methods should be listed.
+	GETMethods []string
+}
+
+// SetHTTPOptions set the HTTP conventions followed by the server.
+// By default, only POST requests are accepted and every response is sent
+// with status 200.
+func (s *JsonRPC2) SetHTTPOptions(opts HTTPOptions) *JsonRPC2 {
+	s.http = opts
+	return s
+}
+
+// statusClientClosedRequest is sent when the client gave up on its
+// request, as done by nginx
+const statusClientClosedRequest = 499
+
+// statusCode return the HTTP status code of a response with the given error.
+// Errors defined by the application are regular responses.
+func statusCode(err *RpcError) int {
+	switch {
+	case err == nil:
+		return http.StatusOK
+	case err.Code == -32600:
+		return http.StatusBadRequest
+	case err.Code == -32601:
+		return http.StatusNotFound
+	case err.Code == CodeUnauthorized:
+		return http.StatusUnauthorized
+	case err.Code == CodeForbidden:
+		return http.StatusForbidden
+	case err.Code == CodeRateLimited:
+		return http.StatusTooManyRequests
+	case err.Code == CodeTimeout:
+		return http.StatusGatewayTimeout
+	case err.Code == CodeOverloaded:
+		return http.StatusServiceUnavailable
+	case err.Code == CodeCanceled:
+		return statusClientClosedRequest
+	case err.Code == -32700, err.Code == -32602, err.Code == -32603:
+		return http.StatusInternalServerError
+	case err.Code >= -32099 && err.Code <= -32000:
+		return http.StatusInternalServerError
+	default:
+		return http.StatusOK
+	}
+}
+
+// isTransportError return true if err rejects the HTTP request itself, the
+// status code of such errors is always sent
+func isTransportError(err *RpcError) bool {
+	if err == nil {
+		return false
+	}
+
+	switch err.Code {
+	case CodeUnauthorized, CodeRateLimited, CodeOverloaded:
+		return true
+	default:
+		return false
+	}
+}
+
+// rateLimitedData return the data of a RateLimited error
+func rateLimitedData(err *RpcError) (*RateLimitedData, bool) {
+	if err == nil || err.Code != CodeRateLimited {
+		return nil, false
+	}
+
+	data, ok := err.Data.(*RateLimitedData)
+	return data, ok
+}
+
+// reply send res to the client, a nil res means the request was a
+// notification
+func (s *JsonRPC2) reply(w http.ResponseWriter, r *http.Request, res *Response) {
+	if res == nil {
+		s.replyEmpty(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", validator.ContentTypeJSON)
+	if data, ok := rateLimitedData(res.Error); ok {
+		w.Header().Set("Retry-After", strconv.FormatInt(data.RetryAfter, 10))
+	}
+	if s.http.StatusCodes || isTransportError(res.Error) {
+		w.WriteHeader(statusCode(res.Error))
+	}
+
+	if err := res.Send(w); err != nil {
+		s.logSendError(r, err)
+	}
+}
+
+// replyError send a response to a request that could not be identified
+func (s *JsonRPC2) replyError(w http.ResponseWriter, r *http.Request, err *RpcError) {
+	s.logRejected(r, err)
+	s.reply(w, r, NewResponse(nil).SetError(err))
+}
+
+// replyHTTPError reject a request that does not follow the HTTP transport
+// rules, status is only sent with the StatusCodes option
+func (s *JsonRPC2) replyHTTPError(w http.ResponseWriter, r *http.Request, status int, err *RpcError) {
+	s.logRejected(r, err)
+	w.Header().Set("Content-Type", validator.ContentTypeJSON)
+	if s.http.StatusCodes {
+		w.WriteHeader(status)
+	}
+
+	_ = NewResponse(nil).SetError(err).Send(w)
+}
+
+// replyBatch send the responses of a batch, nothing is sent if the batch only
+// contains notifications
+func (s *JsonRPC2) replyBatch(w http.ResponseWriter, r *http.Request, batch *Batch) {
+	if len(batch.Get()) == 0 {
+		s.replyEmpty(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", validator.ContentTypeJSON)
+	if err := batch.Send(w); err != nil {
+		s.logSendError(r, err)
+	}
+}
+
+// replyEmpty reply to requests that do not expect any response
+func (s *JsonRPC2) replyEmpty(w http.ResponseWriter) {
+	if s.http.StatusCodes {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// serveGET handle a call sent with GET
+func (s *JsonRPC2) serveGET(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	if err := s.checkPath(r); err != nil {
+		s.replyError(w, r, InvalidRequestError(err))
+		return
+	}
+
+	// The query holds the call, it is authenticated as the request body
+	ctx, ok := s.authenticate(ctx, w, r, []byte(r.URL.RawQuery))
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	if !s.allowGET(query.Get("method")) {
+		s.replyError(w, r, InvalidRequestError(ErrGETNotAllowed))
+		return
+	}
+
+	body, err := queryRequest(query)
+	if err != nil {
+		s.replyError(w, r, ParsingError(err))
+		return
+	}
+
+	if err := s.checkDepth(body); err != nil {
+		s.replyError(w, r, InvalidRequestError(err))
+		return
+	}
+
+	res, md := s.handleCall(ctx, body)
+	writeHeaders(w, md)
+	s.reply(w, r, res)
+}
+
+// allowedMethods return the HTTP methods accepted by the server, as sent in
+// the Allow header
+func (s *JsonRPC2) allowedMethods() string {
+	if len(s.http.GETMethods) > 0 {
+		return "GET, POST"
+	}
+	return "POST"
+}
+
+// allowGET return true if method matches one of the GETMethods patterns
+func (s *JsonRPC2) allowGET(method string) bool {
+	for _, pattern := range s.http.GETMethods {
+		if ok, _ := path.Match(pattern, method); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// queryRequest build the raw request held by query parameters.
+// Params are decoded with queryParams, a numeric id is sent back as a
+// number.
+func queryRequest(query url.Values) ([]byte, error) {
+	method, err := json.Marshal(query.Get("method"))
+	if err != nil {
+		return nil, err
+	}
+
+	req := map[string]json.RawMessage{
+		"jsonrpc": json.RawMessage(`"` + common.JSON_RPC_VERSION + `"`),
+		"method":  method,
+	}
+
+	if params := query.Get("params"); params != "" {
+		if req["params"], err = queryParams(params); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, ok := query["id"]; ok {
+		id := query.Get("id")
+		if _, err := strconv.ParseFloat(id, 64); err == nil && json.Valid([]byte(id)) {
+			req["id"] = json.RawMessage(id)
+		} else if req["id"], err = json.Marshal(id); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(req)
+}
+
+// queryParams decode the params query parameter. The JSON RPC over HTTP
+// convention sends them as base64 encoded JSON, with or without padding.
+// Plain JSON is accepted too since arrays and objects are never valid
+// base64.
+func queryParams(value string) (json.RawMessage, error) {
+	data := []byte(value)
+
+	if value[0] != '[' && value[0] != '{' {
+		var err error
+		for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+			if data, err = enc.DecodeString(value); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return nil, ErrInvalidQueryParams
+		}
+	}
+
+	if !json.Valid(data) {
+		return nil, ErrInvalidQueryParams
+	}
+	return data, nil
+}
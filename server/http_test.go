@@ -0,0 +1,223 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJsonRPC2_ServeHTTP_HTTPOptions(t *testing.T) {
+	s := New(context.TODO()).SetHTTPOptions(HTTPOptions{
+		StatusCodes:        true,
+		RequireContentType: true,
+		GETMethods:         []string{"mock_methodWith*"},
+	})
+	assert.Nil(t, s.Register("mock", &mockService{}))
+
+	testCases := []struct {
+		name             string
+		method           string
+		target           string
+		contentType      string
+		req              []byte
+		expectedStatus   int
+		expectedAllow    string
+		expectedResponse string
+	}{
+		{
+			name:             "Result",
+			method:           http.MethodPost,
+			contentType:      "application/json",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": ["foo"], "id": 1}`),
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"jsonrpc":"2.0","result":"foo","id":1}`,
+		},
+		{
+			name:             "Notification",
+			method:           http.MethodPost,
+			contentType:      "application/json",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": ["foo"]}`),
+			expectedStatus:   http.StatusNoContent,
+			expectedResponse: ``,
+		},
+		{
+			name:             "Notification only batch",
+			method:           http.MethodPost,
+			contentType:      "application/json",
+			req:              []byte(`[{"jsonrpc": "2.0", "method": "mock_methodEmptyArgs"}]`),
+			expectedStatus:   http.StatusNoContent,
+			expectedResponse: ``,
+		},
+		{
+			name:             "Batch with errors",
+			method:           http.MethodPost,
+			contentType:      "application/json",
+			req:              []byte(`[{"jsonrpc": "2.0", "method": "mock_unknown", "id": 1}]`),
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `[{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found","data":"method is not registered"},"id":1}]`,
+		},
+		{
+			name:             "Invalid content type",
+			method:           http.MethodPost,
+			contentType:      "text/plain",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": ["foo"], "id": 1}`),
+			expectedStatus:   http.StatusUnsupportedMediaType,
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"content type should be application/json"},"id":null}`,
+		},
+		{
+			name:             "Invalid HTTP method",
+			method:           http.MethodPut,
+			contentType:      "application/json",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": ["foo"], "id": 1}`),
+			expectedStatus:   http.StatusMethodNotAllowed,
+			expectedAllow:    "GET, POST",
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"http method should be POST"},"id":null}`,
+		},
+		{
+			name:             "Parse error",
+			method:           http.MethodPost,
+			contentType:      "application/json",
+			req:              []byte(`{"jsonrpc": "2.0", "method"`),
+			expectedStatus:   http.StatusInternalServerError,
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error","data":"http request invalid JSON"},"id":null}`,
+		},
+		{
+			name:             "Method not found",
+			method:           http.MethodPost,
+			contentType:      "application/json",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "mock_unknown", "id": 1}`),
+			expectedStatus:   http.StatusNotFound,
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found","data":"method is not registered"},"id":1}`,
+		},
+		{
+			name:             "Invalid params",
+			method:           http.MethodPost,
+			contentType:      "application/json",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": [4], "id": 1}`),
+			expectedStatus:   http.StatusInternalServerError,
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":"invalid arg type"},"id":1}`,
+		},
+		{
+			name:             "GET",
+			method:           http.MethodGet,
+			target:           `/?method=mock_methodWithArgs&params=%5B%22foo%22%2C4%5D&id=1`,
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"jsonrpc":"2.0","result":{"num":4,"str":"foo"},"id":1}`,
+		},
+		{
+			name:             "GET with base64 params",
+			method:           http.MethodGet,
+			target:           `/?method=mock_methodWithArgs&params=WyJmb28iLDRd&id=1`,
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"jsonrpc":"2.0","result":{"num":4,"str":"foo"},"id":1}`,
+		},
+		{
+			name:             "GET with unpadded base64 params",
+			method:           http.MethodGet,
+			target:           `/?method=mock_methodWithArgString&params=WyJmb28iXQ&id=1`,
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"jsonrpc":"2.0","result":"foo","id":1}`,
+		},
+		{
+			name:             "GET with string identifier",
+			method:           http.MethodGet,
+			target:           `/?method=mock_methodWithArgString&params=%5B%22foo%22%5D&id=abc`,
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"jsonrpc":"2.0","result":"foo","id":"abc"}`,
+		},
+		{
+			name:             "GET notification",
+			method:           http.MethodGet,
+			target:           `/?method=mock_methodWithArgString&params=%5B%22foo%22%5D`,
+			expectedStatus:   http.StatusNoContent,
+			expectedResponse: ``,
+		},
+		{
+			name:             "GET invalid params",
+			method:           http.MethodGet,
+			target:           `/?method=mock_methodWithArgString&params=%5B%22foo&id=1`,
+			expectedStatus:   http.StatusInternalServerError,
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error","data":"params should be base64 encoded JSON"},"id":null}`,
+		},
+		{
+			name:             "GET invalid base64 params",
+			method:           http.MethodGet,
+			target:           `/?method=mock_methodWithArgString&params=foo!&id=1`,
+			expectedStatus:   http.StatusInternalServerError,
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error","data":"params should be base64 encoded JSON"},"id":null}`,
+		},
+		{
+			name:             "GET not allowed",
+			method:           http.MethodGet,
+			target:           `/?method=mock_methodEmptyArgs&id=1`,
+			expectedStatus:   http.StatusBadRequest,
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"method can not be called with GET"},"id":null}`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			target := tt.target
+			if target == "" {
+				target = "/"
+			}
+
+			req := httptest.NewRequest(tt.method, target, bytes.NewReader(tt.req))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+
+			s.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedAllow, w.Header().Get("Allow"))
+			assert.Equal(t, tt.expectedResponse, w.Body.String())
+			if tt.expectedResponse != "" {
+				assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestJsonRPC2_ServeHTTP_HTTPErrors(t *testing.T) {
+	// Without StatusCodes, transport errors are sent with 200 OK
+	s := New(context.TODO()).SetHTTPOptions(HTTPOptions{RequireContentType: true})
+	assert.Nil(t, s.Register("mock", &mockService{}))
+
+	body := []byte(`{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": ["foo"], "id": 1}`)
+
+	req := httptest.NewRequest(http.MethodPut, "/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "POST", w.Header().Get("Allow"))
+	assert.Equal(t, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"http method should be POST"},"id":null}`, w.Body.String())
+
+	req = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "text/plain")
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"content type should be application/json"},"id":null}`, w.Body.String())
+}
+
+func TestStatusCode(t *testing.T) {
+	assert.Equal(t, http.StatusOK, statusCode(nil))
+	assert.Equal(t, http.StatusInternalServerError, statusCode(ParsingError(assert.AnError)))
+	assert.Equal(t, http.StatusBadRequest, statusCode(InvalidRequestError(assert.AnError)))
+	assert.Equal(t, http.StatusNotFound, statusCode(MethodNotFoundError(assert.AnError)))
+	assert.Equal(t, http.StatusInternalServerError, statusCode(InvalidParamsError(assert.AnError)))
+	assert.Equal(t, http.StatusInternalServerError, statusCode(InternalError(assert.AnError)))
+	assert.Equal(t, http.StatusInternalServerError, statusCode(CustomError(-32050, assert.AnError)))
+	assert.Equal(t, http.StatusUnauthorized, statusCode(UnauthorizedError(assert.AnError)))
+	assert.Equal(t, statusClientClosedRequest, statusCode(CanceledError(assert.AnError)))
+	assert.Equal(t, http.StatusOK, statusCode(CustomError(42, assert.AnError)))
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"io"
+	"io/ioutil"
+
+	"github.com/TomChv/jsonrpc2/server/validator"
+)
+
+// Limits bound the size of incoming requests.
+// A zero value disables the corresponding limit.
+type Limits struct {
+	// MaxBodySize is the maximum number of bytes read from a request body
+	MaxBodySize int64
+
+	// MaxBatchSize is the maximum number of requests in a batch
+	MaxBatchSize int
+
+	// MaxDepth is the maximum nesting depth of objects and arrays in a
+	// request body
+	MaxDepth int
+}
+
+// SetLimits set the limits applied to incoming requests.
+// By default, requests are not limited.
+func (s *JsonRPC2) SetLimits(limits Limits) *JsonRPC2 {
+	s.limits = limits
+	return s
+}
+
+// readBody read the request body up to the MaxBodySize limit.
+// The body is never buffered beyond the limit.
+func (s *JsonRPC2) readBody(body io.Reader) ([]byte, error) {
+	if s.limits.MaxBodySize <= 0 {
+		return ioutil.ReadAll(body)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(body, s.limits.MaxBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > s.limits.MaxBodySize {
+		return nil, validator.ErrBodyTooLarge
+	}
+
+	return data, nil
+}
+
+// checkDepth verify that data does not exceed the MaxDepth limit
+func (s *JsonRPC2) checkDepth(data []byte) error {
+	if s.limits.MaxDepth <= 0 {
+		return nil
+	}
+	return validator.JSONDepth(data, s.limits.MaxDepth)
+}
+
+// checkBatchSize verify that a batch does not exceed the MaxBatchSize limit
+func (s *JsonRPC2) checkBatchSize(size int) error {
+	if s.limits.MaxBatchSize > 0 && size > s.limits.MaxBatchSize {
+		return validator.ErrBatchTooLarge
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJsonRPC2_ServeHTTP_Limits(t *testing.T) {
+	s := New(context.TODO()).SetLimits(Limits{
+		MaxBodySize:  256,
+		MaxBatchSize: 2,
+		MaxDepth:     4,
+	}).SetBatchMode(BatchOrdered)
+	assert.Nil(t, s.Register("mock", &mockService{}))
+
+	testCases := []struct {
+		name             string
+		req              []byte
+		expectedResponse string
+	}{
+		{
+			name:             "Within limits",
+			req:              []byte(`[{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": ["foo"], "id": 1}]`),
+			expectedResponse: `[{"jsonrpc":"2.0","result":"foo","id":1}]`,
+		},
+		{
+			name:             "Body too large",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": ["` + strings.Repeat("a", 256) + `"], "id": 1}`),
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"request body is too large"},"id":null}`,
+		},
+		{
+			name:             "Batch too large",
+			req:              []byte(`[{"jsonrpc": "2.0", "method": "mock_methodEmptyArgs", "id": 1},{"jsonrpc": "2.0", "method": "mock_methodEmptyArgs", "id": 2},{"jsonrpc": "2.0", "method": "mock_methodEmptyArgs", "id": 3}]`),
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"batch contains too many requests"},"id":null}`,
+		},
+		{
+			name:             "Batch at maximum size",
+			req:              []byte(`[{"jsonrpc": "2.0", "method": "mock_methodEmptyArgs", "id": 1},{"jsonrpc": "2.0", "method": "mock_methodEmptyArgs", "id": 2}]`),
+			expectedResponse: `[{"jsonrpc":"2.0","result":"foo","id":1},{"jsonrpc":"2.0","result":"foo","id":2}]`,
+		},
+		{
+			name:             "Single request too deep",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": [[[["foo"]]]], "id": 1}`),
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"JSON exceeds maximum nesting depth"},"id":null}`,
+		},
+		{
+			name:             "JSON too deep",
+			req:              []byte(`[{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": [[["foo"]]], "id": 1}]`),
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"JSON exceeds maximum nesting depth"},"id":null}`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.req))
+			w := httptest.NewRecorder()
+
+			s.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedResponse, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TomChv/jsonrpc2/common"
+)
+
+// Keys of the structured fields logged by the server
+const (
+	FieldMethod   = "method"
+	FieldID       = "id"
+	FieldDuration = "duration"
+	FieldCode     = "code"
+	FieldRemote   = "remote"
+	FieldError    = "error"
+	FieldAddr     = "addr"
+	FieldParams   = "params"
+	FieldResult   = "result"
+	FieldStack    = "stack"
+
+	// FieldSuppressed counts the warnings dropped since the previous one
+	FieldSuppressed = "suppressed"
+)
+
+// Field is a key value pair attached to a log entry
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Logger receive the log entries of the server.
+// Implementations must be safe for concurrent use.
+type Logger interface {
+	Info(msg string, fields ...Field)
+	Warn(msg string, fields ...Field)
+	Error(msg string, fields ...Field)
+}
+
+// StdLogger is a Logger writing entries as text lines to a log.Logger :
+//
+//	INFO call method=mock_sum id=1 duration=1.2ms remote=127.0.0.1:4242
+type StdLogger struct {
+	l *log.Logger
+}
+
+// NewStdLogger create a Logger writing to l, the standard logger is used if
+// l is nil
+func NewStdLogger(l *log.Logger) *StdLogger {
+	if l == nil {
+		l = log.Default()
+	}
+	return &StdLogger{l: l}
+}
+
+func (l *StdLogger) Info(msg string, fields ...Field) {
+	l.print("INFO", msg, fields)
+}
+
+func (l *StdLogger) Warn(msg string, fields ...Field) {
+	l.print("WARN", msg, fields)
+}
+
+func (l *StdLogger) Error(msg string, fields ...Field) {
+	l.print("ERROR", msg, fields)
+}
+
+func (l *StdLogger) print(level, msg string, fields []Field) {
+	var b strings.Builder
+
+	b.WriteString(level)
+	b.WriteByte(' ')
+	b.WriteString(msg)
+	for _, f := range fields {
+		b.WriteByte(' ')
+		b.WriteString(f.Key)
+		b.WriteByte('=')
+		b.WriteString(fieldValue(f.Value))
+	}
+
+	l.l.Print(b.String())
+}
+
+// fieldValue format a field value, values containing spaces or quotes are
+// quoted
+func fieldValue(v interface{}) string {
+	s := fmt.Sprint(v)
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// NopLogger discard every entry
+type NopLogger struct{}
+
+func (NopLogger) Info(string, ...Field)  {}
+func (NopLogger) Warn(string, ...Field)  {}
+func (NopLogger) Error(string, ...Field) {}
+
+// SetLogger set the logger of the server.
+// Default logger writes to the standard logger, a nil logger discards every
+// entry.
+func (s *JsonRPC2) SetLogger(l Logger) *JsonRPC2 {
+	if l == nil {
+		l = NopLogger{}
+	}
+	s.logger = l
+	return s
+}
+
+// SetAccessLog enable the logging of every call and rejected request.
+// Calls failing because of the server are logged whether access logging is
+// enabled or not. Without access logging, at most one warning about failed
+// calls and rejected requests is logged per second so clients can not flood
+// the logs, it counts the dropped warnings in FieldSuppressed.
+// By default, access logging is disabled.
+func (s *JsonRPC2) SetAccessLog(enabled bool) *JsonRPC2 {
+	s.accessLog = enabled
+	return s
+}
+
+// logCall log a call once it is handled.
+// Server errors are always logged as errors, other failures are logged as
+// sampled warnings and successful calls as infos if access logging is
+// enabled.
+func (s *JsonRPC2) logCall(ctx context.Context, c *call, res *Response, duration time.Duration) {
+	if res.Error == nil && !s.accessLog {
+		return
+	}
+
+	fields := make([]Field, 0, 8)
+	if c != nil {
+		fields = append(fields, Field{FieldMethod, c.Method})
+	}
+	if res.ID != nil && res.ID != common.NullID {
+		fields = append(fields, Field{FieldID, res.ID})
+	}
+	fields = append(fields, Field{FieldDuration, duration})
+	if r := HTTPRequestFromContext(ctx); r != nil {
+		fields = append(fields, Field{FieldRemote, r.RemoteAddr})
+	}
+
+	// Payloads are redacted, see AddRedaction
+	if s.payloadLog {
+		params, result := s.redactCall(c, res)
+		if params != "" {
+			fields = append(fields, Field{FieldParams, params})
+		}
+		if result != "" {
+			fields = append(fields, Field{FieldResult, result})
+		}
+	}
+
+	switch {
+	case res.Error == nil:
+		s.logger.Info("call", fields...)
+	case isServerError(res.Error):
+		s.logger.Error("call failed", append(fields, errorFields(res.Error)...)...)
+	default:
+		s.warnClient("call failed", append(fields, errorFields(res.Error)...)...)
+	}
+}
+
+// logRejected log a request rejected before any call could be identified
+func (s *JsonRPC2) logRejected(r *http.Request, err *RpcError) {
+	fields := append(errorFields(err), Field{FieldRemote, r.RemoteAddr})
+	s.warnClient("request rejected", fields...)
+}
+
+// warnClient log a warning about a failure caused by the client.
+// Without access logging, warnings are sampled, the next logged one counts
+// the dropped ones.
+func (s *JsonRPC2) warnClient(msg string, fields ...Field) {
+	if !s.accessLog {
+		ok, suppressed := s.clientWarnings.allow()
+		if !ok {
+			return
+		}
+		if suppressed > 0 {
+			fields = append(fields, Field{FieldSuppressed, suppressed})
+		}
+	}
+
+	s.logger.Warn(msg, fields...)
+}
+
+// clientWarningInterval is the minimum interval between two sampled
+// warnings
+const clientWarningInterval = time.Second
+
+// sampler let at most one entry through per interval
+type sampler struct {
+	interval time.Duration
+	now      func() time.Time
+
+	last    time.Time
+	dropped int
+	l       sync.Mutex
+}
+
+func newSampler(interval time.Duration) *sampler {
+	return &sampler{interval: interval, now: time.Now}
+}
+
+// allow return true if an entry can be logged, along with the number of
+// entries dropped since the previous one
+func (sp *sampler) allow() (bool, int) {
+	sp.l.Lock()
+	defer sp.l.Unlock()
+
+	now := sp.now()
+	if !sp.last.IsZero() && now.Sub(sp.last) < sp.interval {
+		sp.dropped++
+		return false, 0
+	}
+
+	dropped := sp.dropped
+	sp.last, sp.dropped = now, 0
+	return true, dropped
+}
+
+// logSendError log a response that could not be sent to the client
+func (s *JsonRPC2) logSendError(r *http.Request, err error) {
+	s.logger.Error("send response", Field{FieldError, err}, Field{FieldRemote, r.RemoteAddr})
+}
+
+// errorFields return the fields describing a RPC error
+func errorFields(err *RpcError) []Field {
+	msg := err.Message
+	switch data := err.Data.(type) {
+	case nil:
+	case *RateLimitedData:
+		msg = fmt.Sprintf("%s: %s", msg, data.Message)
+	default:
+		msg = fmt.Sprintf("%s: %v", msg, data)
+	}
+
+	return []Field{{FieldCode, err.Code}, {FieldError, msg}}
+}
+
+// isServerError return true if err is caused by the server rather than the
+// request
+func isServerError(err *RpcError) bool {
+	return err.Code == -32603 || err.Code == CodeTimeout || err.Code == CodeOverloaded
+}
@@ -0,0 +1,225 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockEntry struct {
+	level  string
+	msg    string
+	fields map[string]interface{}
+}
+
+type mockLogger struct {
+	entries []mockEntry
+	l       sync.Mutex
+}
+
+func (l *mockLogger) Info(msg string, fields ...Field)  { l.add("INFO", msg, fields) }
+func (l *mockLogger) Warn(msg string, fields ...Field)  { l.add("WARN", msg, fields) }
+func (l *mockLogger) Error(msg string, fields ...Field) { l.add("ERROR", msg, fields) }
+
+func (l *mockLogger) add(level, msg string, fields []Field) {
+	l.l.Lock()
+	defer l.l.Unlock()
+
+	e := mockEntry{level: level, msg: msg, fields: map[string]interface{}{}}
+	for _, f := range fields {
+		e.fields[f.Key] = f.Value
+	}
+	l.entries = append(l.entries, e)
+}
+
+// failingWriter is a ResponseWriter that fails to write the body
+type failingWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (w failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewStdLogger(log.New(&buf, "", 0))
+
+	l.Info("call", Field{FieldMethod, "mock_sum"}, Field{FieldID, 1}, Field{FieldDuration, 2 * time.Millisecond})
+	l.Warn("call failed", Field{FieldCode, -32601}, Field{FieldError, "Method not found"})
+	l.Error("empty", Field{FieldError, ""})
+
+	assert.Equal(t, `INFO call method=mock_sum id=1 duration=2ms
+WARN call failed code=-32601 error="Method not found"
+ERROR empty error=""
+`, buf.String())
+}
+
+func TestJsonRPC2_Logger(t *testing.T) {
+	testCases := []struct {
+		name      string
+		accessLog bool
+		path      string
+		body      string
+		expected  []mockEntry
+	}{
+		{
+			name: "Successful call is not logged by default",
+			body: `{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": ["foo"], "id": 1}`,
+		},
+		{
+			name:      "Successful call with access logging",
+			accessLog: true,
+			body:      `{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": ["foo"], "id": 1}`,
+			expected: []mockEntry{{level: "INFO", msg: "call", fields: map[string]interface{}{
+				FieldMethod: "mock_methodWithArgString",
+				FieldID:     1,
+				FieldRemote: "192.0.2.1:1234",
+			}}},
+		},
+		{
+			name: "Invalid params",
+			body: `{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": [4], "id": "a"}`,
+			expected: []mockEntry{{level: "WARN", msg: "call failed", fields: map[string]interface{}{
+				FieldMethod: "mock_methodWithArgString",
+				FieldID:     "a",
+				FieldRemote: "192.0.2.1:1234",
+				FieldCode:   int64(-32602),
+			}}},
+		},
+		{
+			name: "Failed notification",
+			body: `{"jsonrpc": "2.0", "method": "mock_methodErrorOnly", "params": [true]}`,
+			expected: []mockEntry{{level: "ERROR", msg: "call failed", fields: map[string]interface{}{
+				FieldMethod: "mock_methodErrorOnly",
+				FieldRemote: "192.0.2.1:1234",
+				FieldCode:   int64(-32603),
+			}}},
+		},
+		{
+			name:      "Invalid request in batch",
+			accessLog: true,
+			body:      `[1]`,
+			expected: []mockEntry{{level: "WARN", msg: "call failed", fields: map[string]interface{}{
+				FieldRemote: "192.0.2.1:1234",
+				FieldCode:   int64(-32600),
+			}}},
+		},
+		{
+			name: "Rejected request",
+			path: "/other",
+			body: `{"jsonrpc": "2.0", "method": "mock_methodEmptyArgs", "id": 1}`,
+			expected: []mockEntry{{level: "WARN", msg: "request rejected", fields: map[string]interface{}{
+				FieldRemote: "192.0.2.1:1234",
+				FieldCode:   int64(-32600),
+			}}},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &mockLogger{}
+			s := New(context.TODO()).SetLogger(l).SetAccessLog(tt.accessLog)
+			assert.Nil(t, s.Register("mock", &mockService{}))
+
+			path := tt.path
+			if path == "" {
+				path = "/"
+			}
+
+			req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader([]byte(tt.body)))
+			s.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, len(tt.expected), len(l.entries))
+			for i, e := range l.entries {
+				if i >= len(tt.expected) {
+					break
+				}
+				assert.Equal(t, tt.expected[i].level, e.level)
+				assert.Equal(t, tt.expected[i].msg, e.msg)
+				for k, v := range tt.expected[i].fields {
+					assert.Equal(t, v, e.fields[k], k)
+				}
+				if e.msg != "request rejected" {
+					assert.IsType(t, time.Duration(0), e.fields[FieldDuration])
+				}
+				if e.level != "INFO" {
+					assert.NotEmpty(t, e.fields[FieldError])
+				}
+			}
+		})
+	}
+}
+
+func TestJsonRPC2_LoggerSampling(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := &mockLogger{}
+	s := New(context.TODO()).SetLogger(l)
+	s.clientWarnings.now = func() time.Time { return now }
+	assert.Nil(t, s.Register("mock", &mockService{}))
+
+	send := func(body string) {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(body)))
+		s.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	for i := 0; i < 3; i++ {
+		send(`{"jsonrpc": "2.0", "method": "mock_unknown", "id": 1}`)
+	}
+	assert.Len(t, l.entries, 1)
+	assert.Nil(t, l.entries[0].fields[FieldSuppressed])
+
+	// Server errors are not sampled
+	send(`{"jsonrpc": "2.0", "method": "mock_methodErrorOnly", "params": [true], "id": 1}`)
+	assert.Len(t, l.entries, 2)
+	assert.Equal(t, "ERROR", l.entries[1].level)
+
+	// The next warning counts the dropped ones
+	now = now.Add(time.Second)
+	send(`{"jsonrpc": "2.0", "method": "mock_unknown", "id": 1}`)
+	assert.Len(t, l.entries, 3)
+	assert.Equal(t, "WARN", l.entries[2].level)
+	assert.Equal(t, 2, l.entries[2].fields[FieldSuppressed])
+}
+
+func TestJsonRPC2_LoggerSendError(t *testing.T) {
+	l := &mockLogger{}
+	s := New(context.TODO()).SetLogger(l)
+	assert.Nil(t, s.Register("mock", &mockService{}))
+
+	body := `{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": ["foo"], "id": 1}`
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(body)))
+	s.ServeHTTP(failingWriter{httptest.NewRecorder()}, req)
+
+	assert.Equal(t, []mockEntry{{level: "ERROR", msg: "send response", fields: map[string]interface{}{
+		FieldError:  errors.New("connection reset"),
+		FieldRemote: "192.0.2.1:1234",
+	}}}, l.entries)
+}
+
+func TestJsonRPC2_LoggerRegistryWarning(t *testing.T) {
+	l := &mockLogger{}
+	s := New(context.TODO()).SetLogger(l)
+	assert.Nil(t, s.Register("partial", &mockPartialService{}))
+
+	assert.NotEmpty(t, l.entries)
+	assert.Equal(t, "WARN", l.entries[0].level)
+	assert.Equal(t, "skip procedure", l.entries[0].msg)
+	assert.NotNil(t, l.entries[0].fields[FieldError])
+}
+
+func TestJsonRPC2_SetLoggerNil(t *testing.T) {
+	s := New(context.TODO()).SetLogger(nil)
+	assert.Equal(t, NopLogger{}, s.logger)
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{`)))
+	s.ServeHTTP(httptest.NewRecorder(), req)
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"net/http"
+)
+
+// Metadata holds transport-neutral key-value pairs attached to a call.
+// Keys are case-insensitive, they are stored in their canonical form like
+// HTTP headers.
+type Metadata map[string][]string
+
+// Get return the first value associated with key
+func (md Metadata) Get(key string) string {
+	return http.Header(md).Get(key)
+}
+
+// Values return all values associated with key
+func (md Metadata) Values(key string) []string {
+	return http.Header(md).Values(key)
+}
+
+// Set replace the values associated with key by value
+func (md Metadata) Set(key, value string) {
+	http.Header(md).Set(key, value)
+}
+
+// Add append value to the values associated with key
+func (md Metadata) Add(key, value string) {
+	http.Header(md).Add(key, value)
+}
+
+// merge append every value of other to md
+func (md Metadata) merge(other Metadata) {
+	for key, values := range other {
+		for _, value := range values {
+			md.Add(key, value)
+		}
+	}
+}
+
+type contextKey int
+
+const (
+	httpRequestKey contextKey = iota
+	metadataKey
+	responseMetadataKey
+	mountedKey
+	principalKey
+)
+
+// WithMetadata return a copy of ctx that carries md as the metadata of the
+// call.
+// Transports other than HTTP use it to give metadata to handlers.
+func WithMetadata(ctx context.Context, md Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey, md)
+}
+
+// MetadataFromContext return the metadata of the call, over HTTP these are
+// the request headers.
+// It returns nil if the call has no metadata.
+func MetadataFromContext(ctx context.Context) Metadata {
+	md, _ := ctx.Value(metadataKey).(Metadata)
+	return md
+}
+
+// HTTPRequestFromContext return the HTTP request that carried the call, nil
+// if the call was not received over HTTP
+func HTTPRequestFromContext(ctx context.Context) *http.Request {
+	r, _ := ctx.Value(httpRequestKey).(*http.Request)
+	return r
+}
+
+// ResponseMetadata return the metadata sent back with the response of the
+// call, over HTTP they are sent as response headers.
+// Metadata of the calls of a batch are merged in request order.
+//
+// The returned Metadata must not be used concurrently. Outside of a call, it
+// is discarded.
+func ResponseMetadata(ctx context.Context) Metadata {
+	md, ok := ctx.Value(responseMetadataKey).(Metadata)
+	if !ok {
+		return Metadata{}
+	}
+	return md
+}
+
+// withHTTPRequest return the context of the calls carried by r
+func withHTTPRequest(r *http.Request) context.Context {
+	ctx := context.WithValue(r.Context(), httpRequestKey, r)
+	return WithMetadata(ctx, Metadata(r.Header))
+}
+
+// handleCall handle a raw request with its own response metadata
+func (s *JsonRPC2) handleCall(ctx context.Context, data []byte) (*Response, Metadata) {
+	md := Metadata{}
+	res := s.handleMessage(context.WithValue(ctx, responseMetadataKey, md), data)
+	return res, md
+}
+
+// writeHeaders add response metadata to the headers of w
+func writeHeaders(w http.ResponseWriter, md Metadata) {
+	Metadata(w.Header()).merge(md)
+}
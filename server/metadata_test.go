@@ -0,0 +1,147 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TomChv/jsonrpc2/common"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockMetadataService struct{}
+
+func (ms *mockMetadataService) Whoami(ctx context.Context) (map[string]string, error) {
+	r := HTTPRequestFromContext(ctx)
+
+	return map[string]string{
+		"user":   MetadataFromContext(ctx).Get("x-user"),
+		"remote": r.RemoteAddr,
+	}, nil
+}
+
+func (ms *mockMetadataService) Tag(ctx context.Context, tag string, delay int) error {
+	time.Sleep(time.Duration(delay) * time.Millisecond)
+
+	md := ResponseMetadata(ctx)
+	md.Add("X-Tag", tag)
+	md.Add("Set-Cookie", tag+"=true")
+	return nil
+}
+
+func TestMetadata(t *testing.T) {
+	md := Metadata{}
+	md.Set("x-foo", "bar")
+	md.Add("X-Foo", "baz")
+
+	assert.Equal(t, "bar", md.Get("X-FOO"))
+	assert.Equal(t, []string{"bar", "baz"}, md.Values("x-foo"))
+
+	other := Metadata{}
+	other.Add("x-foo", "qux")
+	md.merge(other)
+	assert.Equal(t, []string{"bar", "baz", "qux"}, md.Values("x-foo"))
+}
+
+func TestMetadataFromContext(t *testing.T) {
+	ctx := context.TODO()
+	assert.Nil(t, MetadataFromContext(ctx))
+	assert.Nil(t, HTTPRequestFromContext(ctx))
+
+	// Response metadata outside of a call are discarded
+	ResponseMetadata(ctx).Set("x-foo", "bar")
+
+	md := Metadata{}
+	md.Set("x-foo", "bar")
+
+	var got Metadata
+	s := New(context.TODO())
+	assert.Nil(t, s.RegisterHandler("metadata", HandlerFunc(func(ctx context.Context, req *common.Request) (interface{}, *common.RpcError) {
+		got = MetadataFromContext(ctx)
+		return nil, nil
+	})))
+
+	res, _ := s.handleCall(WithMetadata(context.TODO(), md), []byte(`{"jsonrpc": "2.0", "method": "metadata", "id": 1}`))
+	assert.Nil(t, res.Error)
+	assert.Equal(t, md, got)
+}
+
+func TestJsonRPC2_ServeHTTP_Metadata(t *testing.T) {
+	s := New(context.TODO())
+	assert.Nil(t, s.Register("md", &mockMetadataService{}))
+
+	testCases := []struct {
+		name             string
+		req              []byte
+		expectedTags     []string
+		expectedResponse string
+	}{
+		{
+			name:             "Request metadata",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "md_whoami", "id": 1}`),
+			expectedTags:     nil,
+			expectedResponse: `{"jsonrpc":"2.0","result":{"remote":"192.0.2.1:1234","user":"john"},"id":1}`,
+		},
+		{
+			name:             "Response metadata",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "md_tag", "params": ["foo", 0], "id": 1}`),
+			expectedTags:     []string{"foo"},
+			expectedResponse: `{"jsonrpc":"2.0","result":null,"id":1}`,
+		},
+		{
+			name:             "Notification metadata",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "md_tag", "params": ["foo", 0]}`),
+			expectedTags:     []string{"foo"},
+			expectedResponse: ``,
+		},
+		{
+			name: "Batch metadata are merged in request order",
+			req: []byte(`[
+				{"jsonrpc": "2.0", "method": "md_tag", "params": ["first", 30], "id": 1},
+				{"jsonrpc": "2.0", "method": "md_tag", "params": ["second", 15]},
+				{"jsonrpc": "2.0", "method": "md_tag", "params": ["third", 0], "id": 3}
+			]`),
+			expectedTags: []string{"first", "second", "third"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.req))
+			req.Header.Set("X-User", "john")
+			w := httptest.NewRecorder()
+
+			s.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedTags, w.Header().Values("X-Tag"))
+			if tt.expectedResponse != "" {
+				assert.Equal(t, tt.expectedResponse, w.Body.String())
+			}
+
+			var cookies []string
+			for _, c := range w.Result().Cookies() {
+				cookies = append(cookies, c.Name)
+			}
+			assert.Equal(t, tt.expectedTags, cookies)
+		})
+	}
+}
+
+func TestJsonRPC2_ServeHTTP_Metadata_Batch(t *testing.T) {
+	s := New(context.TODO())
+	assert.Nil(t, s.Register("md", &mockMetadataService{}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`[{"jsonrpc": "2.0", "method": "md_whoami", "id": 1}]`)))
+	req.Header.Set("X-User", "john")
+	w := httptest.NewRecorder()
+
+	s.ServeHTTP(w, req)
+
+	var res []map[string]interface{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, map[string]interface{}{"remote": "192.0.2.1:1234", "user": "john"}, res[0]["result"])
+}
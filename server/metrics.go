@@ -0,0 +1,214 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	latencyBuckets   = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	batchSizeBuckets = []float64{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000}
+)
+
+// histogram counts observations in cumulative buckets
+type histogram struct {
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)),
+	}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, b := range h.buckets {
+		if v <= b {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// errorKey identifies the errors of a method
+type errorKey struct {
+	method string
+	code   int64
+}
+
+// metrics collects the metrics of a server
+type metrics struct {
+	calls     map[string]uint64
+	errors    map[errorKey]uint64
+	latency   map[string]*histogram
+	batchSize *histogram
+	inFlight  int64
+	l         sync.Mutex
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		calls:     make(map[string]uint64),
+		errors:    make(map[errorKey]uint64),
+		latency:   make(map[string]*histogram),
+		batchSize: newHistogram(batchSizeBuckets),
+	}
+}
+
+// begin record the start of a call
+func (m *metrics) begin() {
+	m.l.Lock()
+	m.inFlight++
+	m.l.Unlock()
+}
+
+// end record the outcome of a call started with begin
+func (m *metrics) end(method string, err *RpcError, duration time.Duration) {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	m.inFlight--
+	m.calls[method]++
+	if err != nil {
+		m.errors[errorKey{method: method, code: err.Code}]++
+	}
+
+	h, ok := m.latency[method]
+	if !ok {
+		h = newHistogram(latencyBuckets)
+		m.latency[method] = h
+	}
+	h.observe(duration.Seconds())
+}
+
+// observeBatch record the size of a batch request
+func (m *metrics) observeBatch(size int) {
+	m.l.Lock()
+	m.batchSize.observe(float64(size))
+	m.l.Unlock()
+}
+
+// EnableMetrics collect metrics about calls, see MetricsHandler
+func (s *JsonRPC2) EnableMetrics() *JsonRPC2 {
+	s.metrics = newMetrics()
+	return s
+}
+
+// MetricsHandler serve the metrics of the server in Prometheus text
+// exposition format :
+//   - jsonrpc_calls_total : number of calls by method
+//   - jsonrpc_errors_total : number of errors by method and code
+//   - jsonrpc_in_flight_calls : number of calls being handled
+//   - jsonrpc_call_duration_seconds : histogram of call durations by method
+//   - jsonrpc_batch_size : histogram of batch request sizes
+//
+// Metrics are only collected once EnableMetrics is called. Invalid requests
+// and methods that are not registered are labelled "unknown".
+func (s *JsonRPC2) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if s.metrics != nil {
+			s.metrics.write(w)
+		}
+	})
+}
+
+// methodLabel return the method label of a call: the name of the called
+// procedure as registered, or the pattern of the route serving it, so
+// method casing and the names matched by a route do not create new series
+func (s *JsonRPC2) methodLabel(c *call) string {
+	if c == nil {
+		return unknownMethod
+	}
+
+	if s.discovery && c.Method == DiscoverMethod {
+		return c.Method
+	}
+
+	return c.key()
+}
+
+// write the metrics in Prometheus text exposition format
+func (m *metrics) write(w io.Writer) {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	methods := make([]string, 0, len(m.calls))
+	for method := range m.calls {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	fmt.Fprintln(w, "# HELP jsonrpc_calls_total Number of calls by method.")
+	fmt.Fprintln(w, "# TYPE jsonrpc_calls_total counter")
+	for _, method := range methods {
+		fmt.Fprintf(w, "jsonrpc_calls_total{method=%s} %d\n", labelValue(method), m.calls[method])
+	}
+
+	keys := make([]errorKey, 0, len(m.errors))
+	for key := range m.errors {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+		return keys[i].code < keys[j].code
+	})
+
+	fmt.Fprintln(w, "# HELP jsonrpc_errors_total Number of calls that returned an error by method and code.")
+	fmt.Fprintln(w, "# TYPE jsonrpc_errors_total counter")
+	for _, key := range keys {
+		fmt.Fprintf(w, "jsonrpc_errors_total{method=%s,code=\"%d\"} %d\n", labelValue(key.method), key.code, m.errors[key])
+	}
+
+	fmt.Fprintln(w, "# HELP jsonrpc_in_flight_calls Number of calls being handled.")
+	fmt.Fprintln(w, "# TYPE jsonrpc_in_flight_calls gauge")
+	fmt.Fprintf(w, "jsonrpc_in_flight_calls %d\n", m.inFlight)
+
+	fmt.Fprintln(w, "# HELP jsonrpc_call_duration_seconds Duration of calls by method.")
+	fmt.Fprintln(w, "# TYPE jsonrpc_call_duration_seconds histogram")
+	for _, method := range methods {
+		m.latency[method].write(w, "jsonrpc_call_duration_seconds", "method="+labelValue(method)+",")
+	}
+
+	fmt.Fprintln(w, "# HELP jsonrpc_batch_size Number of calls by batch request.")
+	fmt.Fprintln(w, "# TYPE jsonrpc_batch_size histogram")
+	m.batchSize.write(w, "jsonrpc_batch_size", "")
+}
+
+// write the histogram series, labels are prepended to the le label
+func (h *histogram) write(w io.Writer, name string, labels string) {
+	for i, b := range h.buckets {
+		fmt.Fprintf(w, "%s_bucket{%sle=\"%s\"} %d\n", name, labels, formatFloat(b), h.counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{%sle=\"+Inf\"} %d\n", name, labels, h.count)
+
+	labels = strings.TrimSuffix(labels, ",")
+	if labels != "" {
+		labels = "{" + labels + "}"
+	}
+	fmt.Fprintf(w, "%s_sum%s %s\n", name, labels, formatFloat(h.sum))
+	fmt.Fprintf(w, "%s_count%s %d\n", name, labels, h.count)
+}
+
+// labelValue quote and escape a label value
+func labelValue(v string) string {
+	v = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+	return `"` + v + `"`
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
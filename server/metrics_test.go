@@ -0,0 +1,132 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TomChv/jsonrpc2/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogram(t *testing.T) {
+	h := newHistogram([]float64{1, 5})
+	h.observe(0.5)
+	h.observe(3)
+	h.observe(10)
+
+	var buf bytes.Buffer
+	h.write(&buf, "size", `method="foo",`)
+
+	assert.Equal(t, `size_bucket{method="foo",le="1"} 1
+size_bucket{method="foo",le="5"} 2
+size_bucket{method="foo",le="+Inf"} 3
+size_sum{method="foo"} 13.5
+size_count{method="foo"} 3
+`, buf.String())
+
+	buf.Reset()
+	h.write(&buf, "size", "")
+	assert.Contains(t, buf.String(), "size_bucket{le=\"1\"} 1\n")
+	assert.Contains(t, buf.String(), "size_sum 13.5\n")
+}
+
+func TestLabelValue(t *testing.T) {
+	assert.Equal(t, `"foo"`, labelValue("foo"))
+	assert.Equal(t, `"a\\b\"c\nd"`, labelValue("a\\b\"c\nd"))
+}
+
+func TestJsonRPC2_MetricsHandler(t *testing.T) {
+	s := New(context.TODO()).EnableMetrics()
+	assert.Nil(t, s.Register("mock", &mockService{}))
+	assert.Nil(t, s.RegisterRoute("debug_*", HandlerFunc(func(context.Context, *common.Request) (interface{}, *common.RpcError) {
+		return nil, nil
+	})))
+
+	for _, body := range []string{
+		`{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": ["foo"], "id": 1}`,
+		`{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": [4], "id": 2}`,
+		`{"jsonrpc": "2.0", "method": "mock_random", "id": 3}`,
+		`[{"jsonrpc": "2.0", "method": "mock_methodEmptyArgs", "id": 4}, {"jsonrpc": "2.0", "method": "mock_methodEmptyArgs"}, 1]`,
+		`{"jsonrpc": "2.0", "method": "mock_MethodEmptyArgs", "id": 5}`,
+		`{"jsonrpc": "2.0", "method": "debug_foo", "id": 6}`,
+		`{"jsonrpc": "2.0", "method": "debug_bar", "id": 7}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(body)))
+		s.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	w := httptest.NewRecorder()
+	s.MetricsHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", w.Header().Get("Content-Type"))
+
+	body := w.Body.String()
+	assert.NotContains(t, body, "mock_MethodEmptyArgs")
+	assert.NotContains(t, body, "debug_foo")
+	for _, line := range []string{
+		`jsonrpc_calls_total{method="mock_methodEmptyArgs"} 3`,
+		`jsonrpc_calls_total{method="debug_*"} 2`,
+		`jsonrpc_calls_total{method="mock_methodWithArgString"} 2`,
+		`jsonrpc_calls_total{method="unknown"} 2`,
+		`jsonrpc_errors_total{method="mock_methodWithArgString",code="-32602"} 1`,
+		`jsonrpc_errors_total{method="unknown",code="-32601"} 1`,
+		`jsonrpc_errors_total{method="unknown",code="-32600"} 1`,
+		`jsonrpc_in_flight_calls 0`,
+		`jsonrpc_call_duration_seconds_count{method="mock_methodWithArgString"} 2`,
+		`jsonrpc_call_duration_seconds_bucket{method="mock_methodEmptyArgs",le="+Inf"} 3`,
+		`jsonrpc_batch_size_bucket{le="2"} 0`,
+		`jsonrpc_batch_size_bucket{le="5"} 1`,
+		`jsonrpc_batch_size_count 1`,
+		`# TYPE jsonrpc_call_duration_seconds histogram`,
+	} {
+		assert.Contains(t, body, line+"\n")
+	}
+
+	// Series are sorted
+	assert.Less(t,
+		strings.Index(body, `jsonrpc_calls_total{method="mock_methodEmptyArgs"}`),
+		strings.Index(body, `jsonrpc_calls_total{method="mock_methodWithArgString"}`),
+	)
+}
+
+func TestJsonRPC2_MetricsHandler_InFlight(t *testing.T) {
+	service := &mockBlockingService{started: make(chan struct{}, 1), unblock: make(chan struct{})}
+	s := New(context.TODO()).EnableMetrics()
+	assert.Nil(t, s.Register("block", service))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{"jsonrpc": "2.0", "method": "block_wait", "id": 1}`)))
+		s.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+	<-service.started
+
+	w := httptest.NewRecorder()
+	s.MetricsHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, w.Body.String(), "jsonrpc_in_flight_calls 1\n")
+
+	service.unblock <- struct{}{}
+	<-done
+
+	assert.Eventually(t, func() bool {
+		w := httptest.NewRecorder()
+		s.MetricsHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		return strings.Contains(w.Body.String(), "jsonrpc_in_flight_calls 0\n")
+	}, time.Second, time.Millisecond)
+}
+
+func TestJsonRPC2_MetricsHandler_Disabled(t *testing.T) {
+	s := New(context.TODO())
+
+	w := httptest.NewRecorder()
+	s.MetricsHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "", w.Body.String())
+}
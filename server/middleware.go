@@ -0,0 +1,43 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/TomChv/jsonrpc2/server/validator"
+)
+
+// Middleware wraps the HTTP handler of a server, it can inspect or reject
+// requests before they are parsed and dispatched
+type Middleware func(next http.Handler) http.Handler
+
+// Use add middlewares to the server.
+// Middlewares are run in the order they are added, the first one receives
+// requests first.
+func (s *JsonRPC2) Use(middlewares ...Middleware) *JsonRPC2 {
+	s.middlewares = append(s.middlewares, middlewares...)
+
+	var h http.Handler = http.HandlerFunc(s.serve)
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
+		h = s.middlewares[i](h)
+	}
+	s.entry = h
+
+	return s
+}
+
+// SetPath set the path targeted by requests, default is /.
+// An empty path accepts requests on any path, so a router can decide which
+// requests are given to the server.
+func (s *JsonRPC2) SetPath(path string) *JsonRPC2 {
+	s.path = path
+	return s
+}
+
+// checkPath verify that r targets the server path, requests given by a Mux
+// matched the path the server is mounted on instead
+func (s *JsonRPC2) checkPath(r *http.Request) error {
+	if mounted, _ := r.Context().Value(mountedKey).(bool); mounted {
+		return nil
+	}
+	return validator.HTTPPath(r, s.path)
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJsonRPC2_Use(t *testing.T) {
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	reject := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Reject") != "" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	s := New(context.TODO()).Use(trace("first"), trace("second")).Use(reject)
+	assert.Nil(t, s.Register("mock", &mockService{}))
+
+	body := []byte(`{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": ["foo"], "id": 1}`)
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body)))
+	assert.Equal(t, []string{"first", "second"}, calls)
+	assert.Equal(t, `{"jsonrpc":"2.0","result":"foo","id":1}`, w.Body.String())
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set("X-Reject", "true")
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, ``, w.Body.String())
+}
+
+func TestJsonRPC2_SetPath(t *testing.T) {
+	testCases := []struct {
+		name             string
+		path             string
+		target           string
+		expectedResponse string
+	}{
+		{
+			name:             "Default path",
+			path:             "/",
+			target:           "/",
+			expectedResponse: `{"jsonrpc":"2.0","result":"foo","id":1}`,
+		},
+		{
+			name:             "Mounted path",
+			path:             "/rpc",
+			target:           "/rpc",
+			expectedResponse: `{"jsonrpc":"2.0","result":"foo","id":1}`,
+		},
+		{
+			name:             "Invalid path",
+			path:             "/rpc",
+			target:           "/",
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"http request should target the server path"},"id":null}`,
+		},
+		{
+			name:             "Any path",
+			path:             "",
+			target:           "/foo/bar",
+			expectedResponse: `{"jsonrpc":"2.0","result":"foo","id":1}`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(context.TODO()).SetPath(tt.path)
+			assert.Nil(t, s.Register("mock", &mockService{}))
+
+			req := httptest.NewRequest(http.MethodPost, tt.target, bytes.NewReader([]byte(`{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": ["foo"], "id": 1}`)))
+			w := httptest.NewRecorder()
+
+			s.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedResponse, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/TomChv/jsonrpc2/server/validator"
+)
+
+var ErrPathAlreadyMounted = errors.New("a server is already mounted on this path")
+
+// Mux serves several independent servers from one listener, each server is
+// mounted on its own path with its own services and middlewares
+type Mux struct {
+	servers map[string]*JsonRPC2
+	l       sync.RWMutex
+}
+
+// NewMux create an empty Mux
+func NewMux() *Mux {
+	return &Mux{
+		servers: make(map[string]*JsonRPC2),
+	}
+}
+
+// Mount serve s on path.
+// The server is left unchanged: the path set with SetPath is ignored for
+// requests given by the Mux, and still enforced when s is served directly.
+func (m *Mux) Mount(path string, s *JsonRPC2) error {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	if _, ok := m.servers[path]; ok {
+		return ErrPathAlreadyMounted
+	}
+
+	m.servers[path] = s
+	return nil
+}
+
+// Implement HTTP interface to give requests to the server mounted on their
+// path
+func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.l.RLock()
+	s, ok := m.servers[r.URL.Path]
+	m.l.RUnlock()
+
+	if !ok {
+		w.Header().Set("Content-Type", validator.ContentTypeJSON)
+		w.WriteHeader(http.StatusNotFound)
+		_ = NewResponse(nil).SetError(InvalidRequestError(validator.ErrInvalidPathRequest)).Send(w)
+		return
+	}
+
+	// The request already matched the path the server is mounted on
+	s.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), mountedKey, true)))
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockV1Service struct{}
+
+func (ms *mockV1Service) Version() string {
+	return "v1"
+}
+
+type mockV2Service struct{}
+
+func (ms *mockV2Service) Version() string {
+	return "v2"
+}
+
+func TestMux_Mount(t *testing.T) {
+	m := NewMux()
+	assert.Nil(t, m.Mount("/v1", New(context.TODO())))
+	assert.ErrorIs(t, m.Mount("/v1", New(context.TODO())), ErrPathAlreadyMounted)
+}
+
+func TestMux_Mount_KeepsServerPath(t *testing.T) {
+	s := New(context.TODO())
+	assert.Nil(t, s.Register("app", &mockV1Service{}))
+
+	m := NewMux()
+	assert.Nil(t, m.Mount("/v1", s))
+
+	body := `{"jsonrpc": "2.0", "method": "app_version", "id": 1}`
+
+	// The server still answers on its own path when served directly
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(body))))
+	assert.Equal(t, `{"jsonrpc":"2.0","result":"v1","id":1}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1", bytes.NewReader([]byte(body))))
+	assert.Equal(t, `{"jsonrpc":"2.0","result":"v1","id":1}`, w.Body.String())
+}
+
+func TestMux_ServeHTTP(t *testing.T) {
+	v1 := New(context.TODO())
+	assert.Nil(t, v1.Register("app", &mockV1Service{}))
+
+	v2 := New(context.TODO())
+	assert.Nil(t, v2.Register("app", &mockV2Service{}))
+
+	m := NewMux()
+	assert.Nil(t, m.Mount("/v1", v1))
+	assert.Nil(t, m.Mount("/v2", v2))
+
+	testCases := []struct {
+		name             string
+		target           string
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{
+			name:             "First server",
+			target:           "/v1",
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"jsonrpc":"2.0","result":"v1","id":1}`,
+		},
+		{
+			name:             "Second server",
+			target:           "/v2",
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"jsonrpc":"2.0","result":"v2","id":1}`,
+		},
+		{
+			name:             "Unknown path",
+			target:           "/v3",
+			expectedStatus:   http.StatusNotFound,
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"http request should target the server path"},"id":null}`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.target, bytes.NewReader([]byte(`{"jsonrpc": "2.0", "method": "app_version", "id": 1}`)))
+			w := httptest.NewRecorder()
+
+			m.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedResponse, w.Body.String())
+		})
+	}
+}
@@ -1,108 +1,211 @@
 package parser
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"reflect"
 )
 
 var (
-	ErrInvalidArgExpectedSlice = errors.New("invalid argument, expected slice")
-	ErrInvalidArgType          = errors.New("invalid arg type")
-	ErrNoParamFound            = errors.New("expected parameters but no one found")
+	ErrInvalidArgType   = errors.New("invalid arg type")
+	ErrInvalidArgsCount = errors.New("invalid number of arguments")
+	ErrNoParamFound     = errors.New("expected parameters but no one found")
 )
 
-// Arguments convert any param into types send in args
-//   If no args         -> return empty
-//   If 1 arg           -> directly parse the param and return it a single
-//   IF 2 or more arg   -> verify that param is an array and loop through it to
-//  convert it to an array of interface with correct type
-//  Argument type must be type of struct (object) or array
-func Arguments(args []reflect.Type, param interface{}) ([]interface{}, error) {
-	if len(args) == 0 {
-		return []interface{}{}, nil
+var null = []byte("null")
+
+// Decoder converts raw params into the argument types of a method.
+//
+// A Decoder only depends on the method signature, so it can be built once
+// and reused for every call.
+//
+// Trailing pointer arguments are optional, they are set to nil when
+// omitted. If the method is variadic, its last argument absorbs the
+// remaining params.
+type Decoder struct {
+	args     []reflect.Type
+	variadic bool
+
+	// required is the number of leading arguments that can not be omitted
+	required int
+}
+
+// NewDecoder create a Decoder for the given argument types.
+// If variadic is true, the last argument type must be a slice.
+func NewDecoder(args []reflect.Type, variadic bool) *Decoder {
+	d := &Decoder{
+		args:     args,
+		variadic: variadic,
+		required: len(args),
 	}
 
-	if param == nil {
-		return nil, ErrNoParamFound
+	if variadic {
+		d.required--
+	}
+	for d.required > 0 && args[d.required-1].Kind() == reflect.Ptr {
+		d.required--
 	}
 
-	paramKind := reflect.TypeOf(param).Kind()
-	switch {
-	// If argument is type of struct or if it's only 1 argument that is type of array
-	case paramKind == reflect.Struct, paramKind == reflect.Map,
-		len(args) == 1 && args[0].Kind() == reflect.Slice:
-		p, err := parseArgument(args[0], param)
-		if err != nil {
-			return nil, err
+	return d
+}
+
+// Decode convert raw params into values of the decoder argument types.
+// Each param is decoded once, straight into its argument type :
+//   - If no args -> return empty, params must be omitted, null, [] or {}
+//   - If params is an object or the only arg is an array -> decode params as
+//     the first argument
+//   - If params is an array -> decode each element into its argument
+//   - Otherwise, it returns an error
+//
+// Omitted arguments are set to their zero value, the variadic argument is
+// always returned as a slice.
+func (d *Decoder) Decode(params json.RawMessage) ([]reflect.Value, error) {
+	if len(d.args) == 0 {
+		if !empty(params) {
+			return nil, ErrInvalidArgsCount
 		}
+		return []reflect.Value{}, nil
+	}
 
-		return []interface{}{p}, err
-	case paramKind == reflect.Slice:
-		params, err := convertInterfaceToArray(param)
-		if err != nil {
-			return nil, err
+	params = bytes.TrimSpace(params)
+	if len(params) == 0 || bytes.Equal(params, null) {
+		if d.required > 0 {
+			return nil, ErrNoParamFound
+		}
+		return d.complete(nil), nil
+	}
+
+	switch {
+	// If params is an object or if it's only 1 argument that is type of array
+	case params[0] == '{', params[0] == '[' && len(d.args) == 1 && d.args[0].Kind() == reflect.Slice:
+		if d.required > 1 {
+			return nil, ErrInvalidArgsCount
 		}
 
-		res := make([]interface{}, len(args))
-		for i, e := range params {
-			p, err := parseArgument(args[i], e)
-			if err != nil {
-				return nil, err
-			}
-			res[i] = p
+		v := reflect.New(d.args[0])
+		if err := json.Unmarshal(params, v.Interface()); err != nil {
+			return nil, ErrInvalidArgType
 		}
 
-		return res, nil
+		return d.complete([]reflect.Value{v.Elem()}), nil
+	case params[0] == '[' && d.variadic:
+		return d.decodeVariadic(params)
+	case params[0] == '[':
+		return d.decodePositional(params)
 	default:
 		return nil, ErrInvalidArgType
 	}
 }
 
-// parseArgument convert the param into the type of the arg
-// Since a simple reflect is not enough to verify if the param is type of arg
-// this function use json.Unmarshal to correctly convert the param
-func parseArgument(arg reflect.Type, param interface{}) (interface{}, error) {
-	expectedType := reflect.StructOf([]reflect.StructField{{
-		Name: "Placeholder",
-		Type: arg,
-	}})
-	expected := reflect.New(expectedType).Interface()
+// decodePositional decode an array of params.
+// Each element of the array is decoded directly into a pointer of the
+// expected argument type, so no intermediate representation is built.
+func (d *Decoder) decodePositional(params json.RawMessage) ([]reflect.Value, error) {
+	ptrs := make([]interface{}, len(d.args))
+	for i, arg := range d.args {
+		ptrs[i] = reflect.New(arg).Interface()
+	}
 
-	placeholder := struct {
-		Placeholder interface{}
-	}{
-		Placeholder: param,
+	// Unmarshal shrinks ptrs to the number of params
+	if err := json.Unmarshal(params, &ptrs); err != nil {
+		return nil, ErrInvalidArgType
 	}
 
-	data, err := json.Marshal(placeholder)
-	if err != nil {
-		return nil, err
+	if len(ptrs) < d.required || len(ptrs) > len(d.args) {
+		return nil, ErrInvalidArgsCount
 	}
 
-	err = json.Unmarshal(data, &expected)
-	if err != nil {
+	res := make([]reflect.Value, len(ptrs), len(d.args))
+	for i, p := range ptrs {
+		// A null element resets its slot, use the zero value instead
+		if p == nil {
+			res[i] = reflect.Zero(d.args[i])
+			continue
+		}
+		res[i] = reflect.ValueOf(p).Elem()
+	}
+
+	return d.complete(res), nil
+}
+
+// decodeVariadic decode an array of params for a variadic method.
+// Params after the fixed arguments are gathered in the variadic slice.
+func (d *Decoder) decodeVariadic(params json.RawMessage) ([]reflect.Value, error) {
+	var elems []json.RawMessage
+	if err := json.Unmarshal(params, &elems); err != nil {
 		return nil, ErrInvalidArgType
 	}
 
-	value := reflect.ValueOf(expected).Elem().FieldByName("Placeholder")
-	return value.Interface(), nil
+	if len(elems) < d.required {
+		return nil, ErrInvalidArgsCount
+	}
+
+	fixed := len(d.args) - 1
+	res := make([]reflect.Value, 0, len(d.args))
+	for i := 0; i < fixed && i < len(elems); i++ {
+		v := reflect.New(d.args[i])
+		if err := json.Unmarshal(elems[i], v.Interface()); err != nil {
+			return nil, ErrInvalidArgType
+		}
+		res = append(res, v.Elem())
+	}
+
+	if len(elems) <= fixed {
+		return d.complete(res), nil
+	}
+
+	rest := reflect.MakeSlice(d.args[fixed], len(elems)-fixed, len(elems)-fixed)
+	for i, elem := range elems[fixed:] {
+		if err := json.Unmarshal(elem, rest.Index(i).Addr().Interface()); err != nil {
+			return nil, ErrInvalidArgType
+		}
+	}
+
+	return append(res, rest), nil
 }
 
-// convertInterfaceToArray is a utility function used to transform
-// an interface into an array of interface
-// The result can then be used to populate arguments to the dispatcher
-func convertInterfaceToArray(value interface{}) ([]interface{}, error) {
-	var out []interface{}
+// empty return true if params hold no param : omitted, null, [] or {}
+func empty(params json.RawMessage) bool {
+	params = bytes.TrimSpace(params)
+	if len(params) == 0 || bytes.Equal(params, null) {
+		return true
+	}
 
-	reflectValue := reflect.ValueOf(value)
-	if reflectValue.Kind() != reflect.Slice {
-		return nil, ErrInvalidArgExpectedSlice
+	var elems []json.RawMessage
+	if err := json.Unmarshal(params, &elems); err == nil {
+		return len(elems) == 0
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(params, &members); err == nil {
+		return len(members) == 0
+	}
+
+	return false
+}
+
+// complete fill omitted arguments with their zero value
+func (d *Decoder) complete(values []reflect.Value) []reflect.Value {
+	for i := len(values); i < len(d.args); i++ {
+		values = append(values, reflect.Zero(d.args[i]))
+	}
+	return values
+}
+
+// Arguments convert raw params into types send in args
+//
+// See Decoder.Decode for the conversion rules.
+func Arguments(args []reflect.Type, params json.RawMessage) ([]interface{}, error) {
+	values, err := NewDecoder(args, false).Decode(params)
+	if err != nil {
+		return nil, err
 	}
 
-	for i := 0; i < reflectValue.Len(); i++ {
-		out = append(out, reflectValue.Index(i).Interface())
+	res := make([]interface{}, len(values))
+	for i, v := range values {
+		res[i] = v.Interface()
 	}
 
-	return out, nil
+	return res, nil
 }
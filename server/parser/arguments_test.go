@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"encoding/json"
 	"reflect"
 	"testing"
 
@@ -18,7 +19,7 @@ func TestArguments(t *testing.T) {
 		name           string
 		success        bool
 		args           []reflect.Type
-		params         interface{}
+		params         json.RawMessage
 		expectedResult []interface{}
 		expectedError  error
 	}{
@@ -34,7 +35,7 @@ func TestArguments(t *testing.T) {
 			name:           "parse one arg : string - invalid type",
 			success:        true,
 			args:           []reflect.Type{reflect.TypeOf("")},
-			params:         "foo",
+			params:         json.RawMessage(`"foo"`),
 			expectedResult: nil,
 			expectedError:  ErrInvalidArgType,
 		},
@@ -42,7 +43,7 @@ func TestArguments(t *testing.T) {
 			name:           "parse one arg : int - invalid type",
 			success:        true,
 			args:           []reflect.Type{reflect.TypeOf(4)},
-			params:         4,
+			params:         json.RawMessage(`4`),
 			expectedResult: nil,
 			expectedError:  ErrInvalidArgType,
 		},
@@ -50,7 +51,7 @@ func TestArguments(t *testing.T) {
 			name:           "parse one arg : boolean - invalid type",
 			success:        true,
 			args:           []reflect.Type{reflect.TypeOf(true)},
-			params:         false,
+			params:         json.RawMessage(`false`),
 			expectedResult: nil,
 			expectedError:  ErrInvalidArgType,
 		},
@@ -58,7 +59,7 @@ func TestArguments(t *testing.T) {
 			name:           "parse one arg : float - invalid type",
 			success:        true,
 			args:           []reflect.Type{reflect.TypeOf(float64(2))},
-			params:         float64(2),
+			params:         json.RawMessage(`2`),
 			expectedResult: nil,
 			expectedError:  ErrInvalidArgType,
 		},
@@ -66,7 +67,7 @@ func TestArguments(t *testing.T) {
 			name:           "parse one arg : array ",
 			success:        true,
 			args:           []reflect.Type{reflect.TypeOf(1)},
-			params:         []int{1},
+			params:         json.RawMessage(`[1]`),
 			expectedResult: []interface{}{1},
 			expectedError:  nil,
 		},
@@ -74,7 +75,7 @@ func TestArguments(t *testing.T) {
 			name:           "parse one arg : object",
 			success:        true,
 			args:           []reflect.Type{reflect.TypeOf(struct{ Foo string }{Foo: ""})},
-			params:         struct{ Foo string }{Foo: "foo"},
+			params:         json.RawMessage(`{"Foo": "foo"}`),
 			expectedResult: []interface{}{struct{ Foo string }{Foo: "foo"}},
 			expectedError:  nil,
 		},
@@ -82,7 +83,7 @@ func TestArguments(t *testing.T) {
 			name:           "parse one arg : array",
 			success:        true,
 			args:           []reflect.Type{reflect.TypeOf([]int{0})},
-			params:         []int{1, 2, 3},
+			params:         json.RawMessage(`[1, 2, 3]`),
 			expectedResult: []interface{}{[]int{1, 2, 3}},
 			expectedError:  nil,
 		},
@@ -90,7 +91,7 @@ func TestArguments(t *testing.T) {
 			name:           "parse on arg : type do not match",
 			success:        false,
 			args:           []reflect.Type{reflect.TypeOf(false)},
-			params:         []interface{}{"test"},
+			params:         json.RawMessage(`["test"]`),
 			expectedResult: nil,
 			expectedError:  ErrInvalidArgType,
 		},
@@ -98,7 +99,7 @@ func TestArguments(t *testing.T) {
 			name:           "parse multi arg : int",
 			success:        true,
 			args:           []reflect.Type{reflect.TypeOf(0), reflect.TypeOf(0)},
-			params:         []int{1, 2},
+			params:         json.RawMessage(`[1, 2]`),
 			expectedResult: []interface{}{1, 2},
 			expectedError:  nil,
 		},
@@ -106,7 +107,7 @@ func TestArguments(t *testing.T) {
 			name:           "parse multi arg : string",
 			success:        true,
 			args:           []reflect.Type{reflect.TypeOf(""), reflect.TypeOf("")},
-			params:         []string{"foo", "bar"},
+			params:         json.RawMessage(`["foo", "bar"]`),
 			expectedResult: []interface{}{"foo", "bar"},
 			expectedError:  nil,
 		},
@@ -114,7 +115,7 @@ func TestArguments(t *testing.T) {
 			name:           "parse multi arg : boolean",
 			success:        true,
 			args:           []reflect.Type{reflect.TypeOf(false), reflect.TypeOf(false)},
-			params:         []bool{false, true},
+			params:         json.RawMessage(`[false, true]`),
 			expectedResult: []interface{}{false, true},
 			expectedError:  nil,
 		},
@@ -122,7 +123,7 @@ func TestArguments(t *testing.T) {
 			name:           "parse multi arg : mix primitive type",
 			success:        true,
 			args:           []reflect.Type{reflect.TypeOf(false), reflect.TypeOf(""), reflect.TypeOf(0)},
-			params:         []interface{}{true, "foo", 5},
+			params:         json.RawMessage(`[true, "foo", 5]`),
 			expectedResult: []interface{}{true, "foo", 5},
 			expectedError:  nil,
 		},
@@ -130,7 +131,7 @@ func TestArguments(t *testing.T) {
 			name:           "parse multi arg : mix primitive type with array",
 			success:        true,
 			args:           []reflect.Type{reflect.TypeOf(false), reflect.TypeOf(""), reflect.TypeOf([]int{0})},
-			params:         []interface{}{true, "foo", []int{1, 2, 3}},
+			params:         json.RawMessage(`[true, "foo", [1, 2, 3]]`),
 			expectedResult: []interface{}{true, "foo", []int{1, 2, 3}},
 			expectedError:  nil,
 		},
@@ -138,7 +139,7 @@ func TestArguments(t *testing.T) {
 			name:           "parse multi arg : mix primitive type with array and object",
 			success:        true,
 			args:           []reflect.Type{reflect.TypeOf(false), reflect.TypeOf(""), reflect.TypeOf([]int{0}), reflect.TypeOf(struct{ Foo string }{Foo: ""})},
-			params:         []interface{}{true, "foo", []int{1, 2, 3}, struct{ Foo string }{Foo: "foo"}},
+			params:         json.RawMessage(`[true, "foo", [1, 2, 3], {"Foo": "foo"}]`),
 			expectedResult: []interface{}{true, "foo", []int{1, 2, 3}, struct{ Foo string }{Foo: "foo"}},
 			expectedError:  nil,
 		},
@@ -146,7 +147,7 @@ func TestArguments(t *testing.T) {
 			name:           "parse multi arg : mix primitive type with array and object",
 			success:        true,
 			args:           []reflect.Type{reflect.TypeOf(false), reflect.TypeOf(""), reflect.TypeOf([]int{0}), reflect.TypeOf(FakeStruct{})},
-			params:         []interface{}{true, "foo", []int{1, 2, 3}, FakeStruct{0, true, "struct"}},
+			params:         json.RawMessage(`[true, "foo", [1, 2, 3], {"Id": 0, "Field1": true, "Field2": "struct"}]`),
 			expectedResult: []interface{}{true, "foo", []int{1, 2, 3}, FakeStruct{0, true, "struct"}},
 			expectedError:  nil,
 		},
@@ -154,7 +155,7 @@ func TestArguments(t *testing.T) {
 			name:           "parse multi arg : type do not match",
 			success:        false,
 			args:           []reflect.Type{reflect.TypeOf(false), reflect.TypeOf("")},
-			params:         []interface{}{true, 4},
+			params:         json.RawMessage(`[true, 4]`),
 			expectedResult: nil,
 			expectedError:  ErrInvalidArgType,
 		},
@@ -169,3 +170,201 @@ func TestArguments(t *testing.T) {
 		})
 	}
 }
+
+func TestDecoder_Decode(t *testing.T) {
+	intPtr := func(i int) *int {
+		return &i
+	}
+
+	testCases := []struct {
+		name           string
+		args           []reflect.Type
+		variadic       bool
+		params         json.RawMessage
+		expectedResult []interface{}
+		expectedError  error
+	}{
+		{
+			name:           "no arguments : empty array",
+			args:           []reflect.Type{},
+			params:         json.RawMessage(`[ ]`),
+			expectedResult: []interface{}{},
+		},
+		{
+			name:           "no arguments : empty object",
+			args:           []reflect.Type{},
+			params:         json.RawMessage(`{}`),
+			expectedResult: []interface{}{},
+		},
+		{
+			name:           "no arguments : null",
+			args:           []reflect.Type{},
+			params:         json.RawMessage(`null`),
+			expectedResult: []interface{}{},
+		},
+		{
+			name:          "no arguments : params",
+			args:          []reflect.Type{},
+			params:        json.RawMessage(`[1, 2, 3]`),
+			expectedError: ErrInvalidArgsCount,
+		},
+		{
+			name:          "no arguments : named params",
+			args:          []reflect.Type{},
+			params:        json.RawMessage(`{"foo": 1}`),
+			expectedError: ErrInvalidArgsCount,
+		},
+		{
+			name:          "no arguments : invalid params",
+			args:          []reflect.Type{},
+			params:        json.RawMessage(`1`),
+			expectedError: ErrInvalidArgsCount,
+		},
+		{
+			name:          "too many params",
+			args:          []reflect.Type{reflect.TypeOf(0)},
+			params:        json.RawMessage(`[1, 2]`),
+			expectedError: ErrInvalidArgsCount,
+		},
+		{
+			name:          "missing param",
+			args:          []reflect.Type{reflect.TypeOf(0), reflect.TypeOf(0)},
+			params:        json.RawMessage(`[1]`),
+			expectedError: ErrInvalidArgsCount,
+		},
+		{
+			name:           "null param",
+			args:           []reflect.Type{reflect.TypeOf(0), reflect.TypeOf("")},
+			params:         json.RawMessage(`[1, null]`),
+			expectedResult: []interface{}{1, ""},
+		},
+		{
+			name:           "optional : given",
+			args:           []reflect.Type{reflect.TypeOf(0), reflect.TypeOf((*int)(nil))},
+			params:         json.RawMessage(`[1, 2]`),
+			expectedResult: []interface{}{1, intPtr(2)},
+		},
+		{
+			name:           "optional : omitted",
+			args:           []reflect.Type{reflect.TypeOf(0), reflect.TypeOf((*int)(nil))},
+			params:         json.RawMessage(`[1]`),
+			expectedResult: []interface{}{1, (*int)(nil)},
+		},
+		{
+			name:           "optional : no params",
+			args:           []reflect.Type{reflect.TypeOf((*int)(nil))},
+			params:         nil,
+			expectedResult: []interface{}{(*int)(nil)},
+		},
+		{
+			name:           "optional : after object",
+			args:           []reflect.Type{reflect.TypeOf(struct{ Foo string }{}), reflect.TypeOf((*int)(nil))},
+			params:         json.RawMessage(`{"Foo": "foo"}`),
+			expectedResult: []interface{}{struct{ Foo string }{Foo: "foo"}, (*int)(nil)},
+		},
+		{
+			name:          "optional : required argument before",
+			args:          []reflect.Type{reflect.TypeOf((*int)(nil)), reflect.TypeOf(0)},
+			params:        json.RawMessage(`[]`),
+			expectedError: ErrInvalidArgsCount,
+		},
+		{
+			name:           "variadic : only variadic",
+			args:           []reflect.Type{reflect.TypeOf([]string{})},
+			variadic:       true,
+			params:         json.RawMessage(`["foo", "bar"]`),
+			expectedResult: []interface{}{[]string{"foo", "bar"}},
+		},
+		{
+			name:           "variadic : fixed and variadic",
+			args:           []reflect.Type{reflect.TypeOf(0), reflect.TypeOf([]string{})},
+			variadic:       true,
+			params:         json.RawMessage(`[1, "foo", "bar"]`),
+			expectedResult: []interface{}{1, []string{"foo", "bar"}},
+		},
+		{
+			name:           "variadic : empty variadic",
+			args:           []reflect.Type{reflect.TypeOf(0), reflect.TypeOf([]string{})},
+			variadic:       true,
+			params:         json.RawMessage(`[1]`),
+			expectedResult: []interface{}{1, []string(nil)},
+		},
+		{
+			name:           "variadic : no params",
+			args:           []reflect.Type{reflect.TypeOf([]string{})},
+			variadic:       true,
+			params:         nil,
+			expectedResult: []interface{}{[]string(nil)},
+		},
+		{
+			name:          "variadic : missing fixed",
+			args:          []reflect.Type{reflect.TypeOf(0), reflect.TypeOf(0), reflect.TypeOf([]string{})},
+			variadic:      true,
+			params:        json.RawMessage(`[1]`),
+			expectedError: ErrInvalidArgsCount,
+		},
+		{
+			name:          "variadic : invalid type",
+			args:          []reflect.Type{reflect.TypeOf(0), reflect.TypeOf([]string{})},
+			variadic:      true,
+			params:        json.RawMessage(`[1, "foo", 2]`),
+			expectedError: ErrInvalidArgType,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := NewDecoder(tt.args, tt.variadic).Decode(tt.params)
+			assert.Equal(t, tt.expectedError, err)
+
+			if tt.expectedResult == nil {
+				assert.Nil(t, values)
+				return
+			}
+
+			res := make([]interface{}, len(values))
+			for i, v := range values {
+				res[i] = v.Interface()
+			}
+			assert.Equal(t, tt.expectedResult, res)
+		})
+	}
+}
+
+func BenchmarkDecoder_Decode(b *testing.B) {
+	type FakeStruct struct {
+		Id     int
+		Field1 bool
+		Field2 string
+	}
+
+	benchmarks := []struct {
+		name   string
+		args   []reflect.Type
+		params json.RawMessage
+	}{
+		{
+			name:   "positional",
+			args:   []reflect.Type{reflect.TypeOf(false), reflect.TypeOf(""), reflect.TypeOf([]int{0}), reflect.TypeOf(FakeStruct{})},
+			params: json.RawMessage(`[true, "foo", [1, 2, 3], {"Id": 0, "Field1": true, "Field2": "struct"}]`),
+		},
+		{
+			name:   "named",
+			args:   []reflect.Type{reflect.TypeOf(FakeStruct{})},
+			params: json.RawMessage(`{"Id": 0, "Field1": true, "Field2": "struct"}`),
+		},
+	}
+
+	for _, bb := range benchmarks {
+		b.Run(bb.name, func(b *testing.B) {
+			d := NewDecoder(bb.args, false)
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := d.Decode(bb.params); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -8,8 +8,10 @@ import (
 var ErrEmptyBatch = errors.New("empty batch")
 
 // Batch parse an array of byte to convert it as an array of raw request
-func Batch(body []byte) ([][]byte, error) {
-	var reqs []interface{}
+//
+// Elements are not decoded, they are sliced out of the body as-is.
+func Batch(body []byte) ([]json.RawMessage, error) {
+	var reqs []json.RawMessage
 	if err := json.Unmarshal(body, &reqs); err != nil {
 		return nil, err
 	}
@@ -18,14 +20,5 @@ func Batch(body []byte) ([][]byte, error) {
 		return nil, ErrEmptyBatch
 	}
 
-	res := [][]byte{}
-	for _, req := range reqs {
-		d, err := json.Marshal(req)
-		if err != nil {
-			return nil, err
-		}
-		res = append(res, d)
-	}
-
-	return res, nil
+	return reqs, nil
 }
@@ -3,25 +3,120 @@ package parser
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"sort"
 
 	"github.com/TomChv/jsonrpc2/common"
 	"github.com/TomChv/jsonrpc2/server/validator"
 )
 
 var (
-	ErrInvalidBody = errors.New("http request invalid body")
+	ErrInvalidBody       = errors.New("http request invalid body")
+	ErrInvalidJSON       = errors.New("http request invalid JSON")
+	ErrUnknownMember     = errors.New("unknown request member")
+	ErrInvalidParamsType = errors.New("params must be an array or an object")
 )
 
+// rawRequest is the wire representation of a Request.
+// Params are kept as raw JSON to be decoded later straight into the
+// arguments types of the called method.
+type rawRequest struct {
+	JsonRpc string           `json:"jsonrpc"`
+	Method  string           `json:"method"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	ID      common.RequestID `json:"id,omitempty"`
+}
+
 // Request convert an array of byte into a valid Request object.
 //
+// Params of the returned request are left undecoded as json.RawMessage.
+//
+// If the body is not valid JSON, it returns ErrInvalidJSON.
 // If the request does not match JSON RPC specification, it returns
 // an error
 // In any case, Request will return a request struct (null or filled) to
 // let server returns an identifier if one is found
 func Request(body []byte) (*common.Request, error) {
+	var raw rawRequest
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, unmarshalError(err)
+	}
+
+	req := common.Request{
+		JsonRpc: raw.JsonRpc,
+		Method:  raw.Method,
+		ID:      raw.ID,
+	}
+	if raw.Params != nil {
+		req.Params = raw.Params
+	}
+
+	if err := validator.JsonRPCRequest(&req); err != nil {
+		return &req, err
+	}
+
+	return &req, nil
+}
+
+// StrictRequest is like Request but it enforces every MUST of the JSON RPC
+// specification :
+//   - members other than jsonrpc, method, params and id are rejected
+//   - members names are case-sensitive
+//   - params must be an array or an object if included
+//   - a null id is kept as common.NullID, the request is not a notification
+func StrictRequest(body []byte) (*common.Request, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil {
+		return nil, unmarshalError(err)
+	}
+
+	// Identifier is decoded first so it can be sent back on error
 	var req common.Request
-	if err := json.Unmarshal(body, &req); err != nil {
-		return nil, ErrInvalidBody
+	if id, ok := members["id"]; ok {
+		if string(id) == "null" {
+			req.ID = common.NullID
+		} else if err := json.Unmarshal(id, &req.ID); err != nil {
+			return nil, ErrInvalidBody
+		}
+	}
+
+	// Members are decoded in a fixed order, so the request returned on error
+	// does not depend on the map iteration order
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if memberRank(names[i]) != memberRank(names[j]) {
+			return memberRank(names[i]) < memberRank(names[j])
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		var err error
+		value := members[name]
+
+		switch name {
+		case "id":
+		case "jsonrpc":
+			err = json.Unmarshal(value, &req.JsonRpc)
+		case "method":
+			err = json.Unmarshal(value, &req.Method)
+		case "params":
+			if value[0] != '[' && value[0] != '{' {
+				err = ErrInvalidParamsType
+			}
+			req.Params = value
+		default:
+			err = fmt.Errorf("%w: %s", ErrUnknownMember, name)
+		}
+
+		if err != nil {
+			// Validation drops an identifier of invalid type
+			_ = validator.JsonRPCRequest(&req)
+			return &req, err
+		}
 	}
 
 	if err := validator.JsonRPCRequest(&req); err != nil {
@@ -30,3 +125,29 @@ func Request(body []byte) (*common.Request, error) {
 
 	return &req, nil
 }
+
+// memberRank order request members, known members come first
+func memberRank(name string) int {
+	switch name {
+	case "jsonrpc":
+		return 0
+	case "method":
+		return 1
+	case "params":
+		return 2
+	case "id":
+		return 3
+	default:
+		return 4
+	}
+}
+
+// unmarshalError convert a decoding error into ErrInvalidJSON if the body is
+// not valid JSON, or ErrInvalidBody otherwise
+func unmarshalError(err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return ErrInvalidJSON
+	}
+	return ErrInvalidBody
+}
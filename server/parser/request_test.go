@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/TomChv/jsonrpc2/common"
@@ -20,6 +21,27 @@ func TestRequest(t *testing.T) {
 			name:           "Missing body",
 			success:        false,
 			expectedResult: nil,
+			expectedError:  ErrInvalidJSON,
+		},
+		{
+			name:           "Invalid JSON",
+			body:           []byte(`{"jsonrpc": "2.0", "method": "foobar, "params": "bar", "baz]`),
+			success:        false,
+			expectedResult: nil,
+			expectedError:  ErrInvalidJSON,
+		},
+		{
+			name:           "Invalid method type",
+			body:           []byte(`{"jsonrpc": "2.0", "method": 1, "params": "bar"}`),
+			success:        false,
+			expectedResult: nil,
+			expectedError:  ErrInvalidBody,
+		},
+		{
+			name:           "Not an object",
+			body:           []byte(`1`),
+			success:        false,
+			expectedResult: nil,
 			expectedError:  ErrInvalidBody,
 		},
 		{
@@ -83,14 +105,14 @@ func TestRequest(t *testing.T) {
 			name:           "With param string",
 			body:           []byte(`{"jsonrpc": "2.0", "method": "/test", "params": "test"}`),
 			success:        true,
-			expectedResult: &common.Request{JsonRpc: "2.0", Method: "/test", Params: "test"},
+			expectedResult: &common.Request{JsonRpc: "2.0", Method: "/test", Params: json.RawMessage(`"test"`)},
 			expectedError:  nil,
 		},
 		{
 			name:           "With param number",
 			body:           []byte(`{"jsonrpc": "2.0", "method": "/test", "params": 4}`),
 			success:        true,
-			expectedResult: &common.Request{JsonRpc: "2.0", Method: "/test", Params: float64(4)},
+			expectedResult: &common.Request{JsonRpc: "2.0", Method: "/test", Params: json.RawMessage(`4`)},
 			expectedError:  nil,
 		},
 		{
@@ -100,10 +122,8 @@ func TestRequest(t *testing.T) {
 			expectedResult: &common.Request{
 				JsonRpc: "2.0",
 				Method:  "/test",
-				Params: map[string]interface{}{
-					"foo": "bar",
-					"baz": float64(4),
-				}},
+				Params:  json.RawMessage(`{"foo": "bar", "baz": 4 }`),
+			},
 			expectedError: nil,
 		},
 		{
@@ -113,13 +133,8 @@ func TestRequest(t *testing.T) {
 			expectedResult: &common.Request{
 				JsonRpc: "2.0",
 				Method:  "/test",
-				Params: map[string]interface{}{
-					"foo": "bar",
-					"baz": float64(4),
-					"fizz": map[string]interface{}{
-						"bool": true,
-					},
-				}},
+				Params:  json.RawMessage(`{"foo": "bar", "baz": 4, "fizz": { "bool": true }}`),
+			},
 			expectedError: nil,
 		},
 		{
@@ -130,13 +145,8 @@ func TestRequest(t *testing.T) {
 				JsonRpc: "2.0",
 				Method:  "/test",
 				ID:      "fake_id",
-				Params: map[string]interface{}{
-					"foo": "bar",
-					"baz": float64(4),
-					"fizz": map[string]interface{}{
-						"bool": true,
-					},
-				}},
+				Params:  json.RawMessage(`{"foo": "bar", "baz": 4, "fizz": { "bool": true }}`),
+			},
 			expectedError: nil,
 		},
 	}
@@ -150,3 +160,82 @@ func TestRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestStrictRequest(t *testing.T) {
+	testCases := []struct {
+		name           string
+		body           []byte
+		expectedResult *common.Request
+		expectedError  error
+	}{
+		{
+			name:           "Invalid JSON",
+			body:           []byte(`{"jsonrpc": "2.0", "method": "foobar, "params": "bar", "baz]`),
+			expectedResult: nil,
+			expectedError:  ErrInvalidJSON,
+		},
+		{
+			name:           "Not an object",
+			body:           []byte(`1`),
+			expectedResult: nil,
+			expectedError:  ErrInvalidBody,
+		},
+		{
+			name:           "Missing json rpc version",
+			body:           []byte(`{"id": 0, "method": "test"}`),
+			expectedResult: &common.Request{Method: "test", ID: 0},
+			expectedError:  validator.ErrInvalidJsonVersion,
+		},
+		{
+			name:           "Unknown member",
+			body:           []byte(`{"jsonrpc": "2.0", "id": 0, "method": "test", "foo": "bar"}`),
+			expectedResult: &common.Request{JsonRpc: "2.0", Method: "test", ID: 0},
+			expectedError:  ErrUnknownMember,
+		},
+		{
+			name:           "Case-sensitive member",
+			body:           []byte(`{"jsonrpc": "2.0", "id": 0, "Method": "test"}`),
+			expectedResult: &common.Request{JsonRpc: "2.0", ID: 0},
+			expectedError:  ErrUnknownMember,
+		},
+		{
+			name:           "Unknown member with invalid identifier",
+			body:           []byte(`{"jsonrpc": "2.0", "id": true, "method": "test", "foo": "bar"}`),
+			expectedResult: &common.Request{JsonRpc: "2.0", Method: "test"},
+			expectedError:  ErrUnknownMember,
+		},
+		{
+			name:           "Scalar params",
+			body:           []byte(`{"jsonrpc": "2.0", "id": 0, "method": "test", "params": 4}`),
+			expectedResult: &common.Request{JsonRpc: "2.0", Method: "test", ID: 0, Params: json.RawMessage(`4`)},
+			expectedError:  ErrInvalidParamsType,
+		},
+		{
+			name:           "Null identifier",
+			body:           []byte(`{"jsonrpc": "2.0", "method": "test", "id": null}`),
+			expectedResult: &common.Request{JsonRpc: "2.0", Method: "test", ID: common.NullID},
+			expectedError:  nil,
+		},
+		{
+			name:           "Notification",
+			body:           []byte(`{"jsonrpc": "2.0", "method": "test", "params": [1, 2]}`),
+			expectedResult: &common.Request{JsonRpc: "2.0", Method: "test", Params: json.RawMessage(`[1, 2]`)},
+			expectedError:  nil,
+		},
+		{
+			name:           "Valid request",
+			body:           []byte(`{"jsonrpc": "2.0", "method": "test", "id": "fake_id", "params": {"foo": "bar"}}`),
+			expectedResult: &common.Request{JsonRpc: "2.0", Method: "test", ID: "fake_id", Params: json.RawMessage(`{"foo": "bar"}`)},
+			expectedError:  nil,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := StrictRequest(tt.body)
+
+			assert.Equal(t, tt.expectedResult, res)
+			assert.ErrorIs(t, err, tt.expectedError)
+		})
+	}
+}
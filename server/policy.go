@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"path"
+)
+
+var (
+	ErrAnonymousCaller = errors.New("method requires an authenticated caller")
+	ErrMissingRole     = errors.New("caller does not have a required role")
+	ErrMissingScope    = errors.New("caller does not have a required scope")
+)
+
+// Policy restricts the callers of the methods matching Pattern.
+// Pattern uses the path.Match syntax, e.g. "admin_*" matches any method of
+// the admin namespace.
+type Policy struct {
+	Pattern string
+
+	// Roles lists the roles allowed to call the methods, the caller must
+	// have one of them
+	Roles []string
+
+	// Scopes lists the scopes required to call the methods, the caller must
+	// have all of them
+	Scopes []string
+}
+
+// AddPolicy restrict the methods matching the policy pattern.
+//
+// Every policy matching a method must be satisfied, methods without policy
+// can be called by anyone. Policies are enforced on each call, including
+// the calls of a batch; denied calls get a Forbidden error.
+// The caller is the principal set by authenticators, see SetAuthenticators.
+func (s *JsonRPC2) AddPolicy(p Policy) error {
+	if p.Pattern == "" {
+		return ErrEmptyMethodName
+	}
+
+	if _, err := path.Match(p.Pattern, ""); err != nil {
+		return ErrInvalidPattern
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.policies = append(s.policies, p)
+	return nil
+}
+
+// authorize verify that the caller satisfies the policies of the method
+// dispatched under name.
+// Policies match the name of the called procedure as registered, so the
+// casing of the method does not bypass them.
+func (s *JsonRPC2) authorize(ctx context.Context, name string) error {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	principal := PrincipalFromContext(ctx)
+	for _, p := range s.policies {
+		// Pattern is validated when the policy is added
+		if ok, _ := path.Match(p.Pattern, name); !ok {
+			continue
+		}
+
+		if principal == nil {
+			return ErrAnonymousCaller
+		}
+
+		if len(p.Roles) > 0 && !containsAny(principal.Roles, p.Roles) {
+			return ErrMissingRole
+		}
+
+		for _, scope := range p.Scopes {
+			if !containsAny(principal.Scopes, []string{scope}) {
+				return ErrMissingScope
+			}
+		}
+	}
+
+	return nil
+}
+
+// containsAny return true if values contains one of expected
+func containsAny(values, expected []string) bool {
+	for _, v := range values {
+		for _, e := range expected {
+			if v == e {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAdminService struct{}
+
+func (ms *mockAdminService) Reset() string {
+	return "reset"
+}
+
+type mockBillingService struct{}
+
+func (ms *mockBillingService) Refund() string {
+	return "refunded"
+}
+
+func (ms *mockBillingService) Invoice() string {
+	return "invoice"
+}
+
+// newPolicyServer create a server where callers are identified by their
+// X-Api-Key
+func newPolicyServer(t *testing.T) *JsonRPC2 {
+	s := New(context.TODO()).SetAuthenticators(
+		APIKeyAuthenticator("X-Api-Key", map[string]*Principal{
+			"admin":  {ID: "admin", Roles: []string{"admin"}},
+			"writer": {ID: "writer", Roles: []string{"user"}, Scopes: []string{"billing:read", "billing:write"}},
+			"reader": {ID: "reader", Roles: []string{"user"}, Scopes: []string{"billing:read"}},
+		}),
+		AuthenticatorFunc(func(r *http.Request, body []byte) (*Principal, error) {
+			return nil, nil
+		}),
+	)
+
+	assert.Nil(t, s.Register("admin", &mockAdminService{}))
+	assert.Nil(t, s.Register("billing", &mockBillingService{}))
+	assert.Nil(t, s.AddPolicy(Policy{Pattern: "admin_*", Roles: []string{"admin"}}))
+	assert.Nil(t, s.AddPolicy(Policy{Pattern: "billing_*", Roles: []string{"admin", "user"}}))
+	assert.Nil(t, s.AddPolicy(Policy{Pattern: "billing_refund", Scopes: []string{"billing:write"}}))
+
+	return s
+}
+
+func TestJsonRPC2_AddPolicy(t *testing.T) {
+	s := New(context.TODO())
+
+	assert.Nil(t, s.AddPolicy(Policy{Pattern: "admin_*", Roles: []string{"admin"}}))
+	assert.Equal(t, ErrEmptyMethodName, s.AddPolicy(Policy{Roles: []string{"admin"}}))
+	assert.Equal(t, ErrInvalidPattern, s.AddPolicy(Policy{Pattern: "admin_[", Roles: []string{"admin"}}))
+}
+
+func TestJsonRPC2_ServeHTTP_Policies(t *testing.T) {
+	s := newPolicyServer(t)
+
+	testCases := []struct {
+		name             string
+		key              string
+		req              []byte
+		expectedResponse string
+	}{
+		{
+			name:             "Admin role",
+			key:              "admin",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "admin_reset", "id": 1}`),
+			expectedResponse: `{"jsonrpc":"2.0","result":"reset","id":1}`,
+		},
+		{
+			name:             "Missing role",
+			key:              "writer",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "admin_reset", "id": 1}`),
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32002,"message":"Forbidden","data":"caller does not have a required role"},"id":1}`,
+		},
+		{
+			name:             "Anonymous caller",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "admin_reset", "id": 1}`),
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32002,"message":"Forbidden","data":"method requires an authenticated caller"},"id":1}`,
+		},
+		{
+			name:             "Required scope",
+			key:              "writer",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "billing_refund", "id": 1}`),
+			expectedResponse: `{"jsonrpc":"2.0","result":"refunded","id":1}`,
+		},
+		{
+			name:             "Missing scope",
+			key:              "reader",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "billing_refund", "id": 1}`),
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32002,"message":"Forbidden","data":"caller does not have a required scope"},"id":1}`,
+		},
+		{
+			name:             "Missing scope with another casing",
+			key:              "reader",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "billing_Refund", "id": 1}`),
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32002,"message":"Forbidden","data":"caller does not have a required scope"},"id":1}`,
+		},
+		{
+			name:             "Every policy must be satisfied",
+			key:              "admin",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "billing_refund", "id": 1}`),
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32002,"message":"Forbidden","data":"caller does not have a required scope"},"id":1}`,
+		},
+		{
+			name: "Batch",
+			key:  "reader",
+			req: []byte(`[
+				{"jsonrpc": "2.0", "method": "billing_invoice", "id": 1},
+				{"jsonrpc": "2.0", "method": "billing_refund", "id": 2}
+			]`),
+			expectedResponse: `[{"jsonrpc":"2.0","result":"invoice","id":1},{"jsonrpc":"2.0","error":{"code":-32002,"message":"Forbidden","data":"caller does not have a required scope"},"id":2}]`,
+		},
+	}
+
+	s.SetBatchMode(BatchOrdered)
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.req))
+			if tt.key != "" {
+				req.Header.Set("X-Api-Key", tt.key)
+			}
+			w := httptest.NewRecorder()
+
+			s.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedResponse, w.Body.String())
+		})
+	}
+}
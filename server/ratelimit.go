@@ -0,0 +1,248 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidRateLimit  = errors.New("rate limit must have a positive rate and burst")
+	ErrRateLimitExceeded = errors.New("rate limit exceeded")
+)
+
+// RateLimitKey identifies the caller a rate limit applies to
+type RateLimitKey func(ctx context.Context) string
+
+// ByRemoteIP limit callers by the IP address of their HTTP request
+func ByRemoteIP(ctx context.Context) string {
+	r := HTTPRequestFromContext(ctx)
+	if r == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ByPrincipal limit callers by their authenticated principal, anonymous
+// callers share the same limit
+func ByPrincipal(ctx context.Context) string {
+	if p := PrincipalFromContext(ctx); p != nil {
+		return p.ID
+	}
+	return ""
+}
+
+// ByMetadata limit callers by the value of a metadata key, e.g. the header
+// that holds their API key
+func ByMetadata(key string) RateLimitKey {
+	return func(ctx context.Context) string {
+		return MetadataFromContext(ctx).Get(key)
+	}
+}
+
+// RateLimit is a token bucket limit on the calls of the methods matching
+// Pattern.
+// Pattern uses the path.Match syntax, an empty pattern matches every method.
+type RateLimit struct {
+	Pattern string
+
+	// Rate is the number of calls allowed per second
+	Rate float64
+
+	// Burst is the number of calls allowed at once
+	Burst int
+
+	// Key identifies callers, each caller has its own bucket.
+	// If nil, every caller shares the same bucket.
+	Key RateLimitKey
+
+	// PerMethod gives each matching method its own bucket, otherwise
+	// matching methods share the bucket of the caller.
+	// Methods served by a route share the bucket of its pattern, methods
+	// that are not registered share one bucket.
+	PerMethod bool
+}
+
+// RateLimitStore holds the token buckets of rate limits
+type RateLimitStore interface {
+	// Take remove a token from the bucket identified by key, which is refilled
+	// with rate tokens per second up to burst tokens.
+	// If the bucket is empty, it returns false with the time to wait for the
+	// next token.
+	Take(key string, rate float64, burst int) (bool, time.Duration)
+
+	// Refund give back a token taken from the bucket identified by key, it is
+	// called when another limit rejects the call
+	Refund(key string)
+}
+
+// AddRateLimit limit the calls of the methods matching the rate limit
+// pattern.
+// Every call counts, including each call of a batch. Rejected calls get a
+// RateLimited error with a retry-after hint.
+func (s *JsonRPC2) AddRateLimit(l RateLimit) error {
+	if l.Rate <= 0 || l.Burst <= 0 {
+		return ErrInvalidRateLimit
+	}
+
+	if _, err := path.Match(l.Pattern, ""); err != nil {
+		return ErrInvalidPattern
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.rateLimits = append(s.rateLimits, l)
+	return nil
+}
+
+// SetRateLimitStore replace the store of token buckets, default is an
+// in-memory store
+func (s *JsonRPC2) SetRateLimitStore(store RateLimitStore) *JsonRPC2 {
+	s.rateLimitStore = store
+	return s
+}
+
+// rateLimit take a token from each rate limit matching the method of c.
+// It returns the longest time to wait if a limit is exceeded, tokens taken
+// from the other limits are then given back.
+//
+// Limits match the name of the called procedure as registered, so the
+// casing of the method does not give a new bucket. Per-method buckets of
+// routes are keyed on their pattern, and methods that are not registered
+// share one bucket, callers could otherwise allocate a bucket per name.
+func (s *JsonRPC2) rateLimit(ctx context.Context, c *call) (time.Duration, bool) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	var wait time.Duration
+	var taken []string
+	for i, l := range s.rateLimits {
+		// Pattern is validated when the rate limit is added
+		if ok, _ := path.Match(l.Pattern, c.name); l.Pattern != "" && !ok {
+			continue
+		}
+
+		key := strconv.Itoa(i) + "/"
+		if l.Key != nil {
+			key += l.Key(ctx)
+		}
+		if l.PerMethod {
+			key += "/" + c.key()
+		}
+
+		ok, retry := s.rateLimitStore.Take(key, l.Rate, l.Burst)
+		if ok {
+			taken = append(taken, key)
+		} else if retry > wait {
+			wait = retry
+		}
+	}
+
+	if wait == 0 {
+		return 0, true
+	}
+
+	for _, key := range taken {
+		s.rateLimitStore.Refund(key)
+	}
+	return wait, false
+}
+
+// retryAfter convert a duration into a number of seconds, rounded up
+func retryAfter(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
+
+// bucket is a token bucket
+type bucket struct {
+	tokens float64
+	burst  float64
+	last   time.Time
+
+	// refill is the time it takes to refill the bucket once empty
+	refill time.Duration
+}
+
+// MemoryRateLimitStore is a RateLimitStore that keeps buckets in memory
+type MemoryRateLimitStore struct {
+	buckets map[string]*bucket
+	now     func() time.Time
+
+	// sweptAt is the last time full buckets were dropped
+	sweptAt time.Time
+	l       sync.Mutex
+}
+
+// NewMemoryRateLimitStore create an empty MemoryRateLimitStore
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Take remove a token from the bucket identified by key
+func (m *MemoryRateLimitStore) Take(key string, rate float64, burst int) (bool, time.Duration) {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{
+			tokens: float64(burst),
+			burst:  float64(burst),
+			last:   now,
+			refill: time.Duration(float64(burst) / rate * float64(time.Second)),
+		}
+		m.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	}
+
+	b.tokens--
+	return true, 0
+}
+
+// Refund give back a token to the bucket identified by key
+func (m *MemoryRateLimitStore) Refund(key string) {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	if b, ok := m.buckets[key]; ok {
+		b.tokens = math.Min(b.burst, b.tokens+1)
+	}
+}
+
+// sweep drop the buckets that have not been used long enough to be full
+// again, so callers that stopped calling do not use memory
+func (m *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(m.sweptAt) < sweepInterval {
+		return
+	}
+	m.sweptAt = now
+
+	for key, b := range m.buckets {
+		if now.Sub(b.last) > b.refill {
+			delete(m.buckets, key)
+		}
+	}
+}
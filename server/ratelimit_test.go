@@ -0,0 +1,196 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryRateLimitStore_Take(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	store := NewMemoryRateLimitStore()
+	store.now = func() time.Time { return now }
+
+	take := func(key string) (bool, time.Duration) {
+		return store.Take(key, 2, 2)
+	}
+
+	assertTake := func(key string, expectedOk bool, expectedWait time.Duration) {
+		t.Helper()
+		ok, wait := take(key)
+		assert.Equal(t, expectedOk, ok)
+		assert.Equal(t, expectedWait, wait)
+	}
+
+	// Burst
+	assertTake("a", true, 0)
+	assertTake("a", true, 0)
+	assertTake("a", false, 500*time.Millisecond)
+
+	// Buckets are independent
+	assertTake("b", true, 0)
+
+	// Refill
+	now = now.Add(250 * time.Millisecond)
+	assertTake("a", false, 250*time.Millisecond)
+	now = now.Add(250 * time.Millisecond)
+	assertTake("a", true, 0)
+	assertTake("a", false, 500*time.Millisecond)
+
+	// Refunds do not exceed the burst
+	store.Refund("b")
+	store.Refund("b")
+	assertTake("b", true, 0)
+	assertTake("b", true, 0)
+	assertTake("b", false, 500*time.Millisecond)
+	store.Refund("unknown")
+
+	// Full buckets are dropped
+	now = now.Add(2 * time.Minute)
+	assertTake("c", true, 0)
+	assert.Len(t, store.buckets, 1)
+}
+
+func TestJsonRPC2_AddRateLimit(t *testing.T) {
+	s := New(context.TODO())
+
+	assert.Nil(t, s.AddRateLimit(RateLimit{Rate: 1, Burst: 1}))
+	assert.Nil(t, s.AddRateLimit(RateLimit{Pattern: "mock_*", Rate: 1, Burst: 1, Key: ByRemoteIP}))
+	assert.Equal(t, ErrInvalidRateLimit, s.AddRateLimit(RateLimit{Rate: 0, Burst: 1}))
+	assert.Equal(t, ErrInvalidRateLimit, s.AddRateLimit(RateLimit{Rate: 1, Burst: 0}))
+	assert.Equal(t, ErrInvalidPattern, s.AddRateLimit(RateLimit{Pattern: "mock_[", Rate: 1, Burst: 1}))
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	ctx := context.TODO()
+	assert.Equal(t, "", ByRemoteIP(ctx))
+	assert.Equal(t, "", ByPrincipal(ctx))
+	assert.Equal(t, "", ByMetadata("X-Api-Key")(ctx))
+
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("X-Api-Key", "secret")
+	ctx = context.WithValue(withHTTPRequest(r), principalKey, &Principal{ID: "john"})
+
+	assert.Equal(t, "192.0.2.1", ByRemoteIP(ctx))
+	assert.Equal(t, "john", ByPrincipal(ctx))
+	assert.Equal(t, "secret", ByMetadata("X-Api-Key")(ctx))
+}
+
+func TestJsonRPC2_ServeHTTP_RateLimit(t *testing.T) {
+	const perMinute = 1.0 / 60
+
+	newServer := func(t *testing.T) *JsonRPC2 {
+		s := New(context.TODO()).SetBatchMode(BatchOrdered)
+		assert.Nil(t, s.Register("mock", &mockService{}))
+		assert.Nil(t, s.AddRateLimit(RateLimit{Rate: perMinute, Burst: 3, Key: ByMetadata("X-Api-Key")}))
+		assert.Nil(t, s.AddRateLimit(RateLimit{Pattern: "mock_methodWithArg*", Rate: perMinute, Burst: 1, Key: ByMetadata("X-Api-Key"), PerMethod: true}))
+		return s
+	}
+
+	send := func(s *JsonRPC2, key string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(body)))
+		req.Header.Set("X-Api-Key", key)
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Per method limit", func(t *testing.T) {
+		s := newServer(t)
+
+		w := send(s, "a", `{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": ["foo"], "id": 1}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		// Another method has its own bucket
+		w = send(s, "a", `{"jsonrpc": "2.0", "method": "mock_methodWithArgNumber", "params": [4], "id": 2}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = send(s, "a", `{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": ["foo"], "id": 3}`)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "60", w.Header().Get("Retry-After"))
+		assert.Equal(t, `{"jsonrpc":"2.0","error":{"code":-32003,"message":"Too many requests","data":{"message":"rate limit exceeded","retryAfter":60}},"id":3}`, w.Body.String())
+
+		// Another caller has its own bucket
+		w = send(s, "b", `{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": ["foo"], "id": 4}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Method casing shares the bucket", func(t *testing.T) {
+		s := newServer(t)
+
+		w := send(s, "a", `{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": ["foo"], "id": 1}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = send(s, "a", `{"jsonrpc": "2.0", "method": "mock_MethodWithArgString", "params": ["foo"], "id": 2}`)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	})
+
+	t.Run("Unknown methods share a per method bucket", func(t *testing.T) {
+		s := newServer(t)
+
+		w := send(s, "a", `{"jsonrpc": "2.0", "method": "mock_methodWithArgFoo", "id": 1}`)
+		assert.Equal(t, `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found","data":"method is not registered"},"id":1}`, w.Body.String())
+
+		w = send(s, "a", `{"jsonrpc": "2.0", "method": "mock_methodWithArgBar", "id": 2}`)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+		// Only the bucket of the caller and the one of unknown methods were
+		// allocated
+		assert.Len(t, s.rateLimitStore.(*MemoryRateLimitStore).buckets, 2)
+	})
+
+	t.Run("Routes share the bucket of their pattern", func(t *testing.T) {
+		s := newServer(t)
+		assert.Nil(t, s.RegisterRoute("mock_methodWithArgRoute*", mockHandler{}))
+
+		w := send(s, "a", `{"jsonrpc": "2.0", "method": "mock_methodWithArgRouteA", "id": 1}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = send(s, "a", `{"jsonrpc": "2.0", "method": "mock_methodWithArgRouteB", "id": 2}`)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	})
+
+	t.Run("Rejected calls do not spend tokens", func(t *testing.T) {
+		s := newServer(t)
+
+		w := send(s, "a", `{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": ["foo"], "id": 1}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		// Rejected by the per method limit, the token of the caller limit is
+		// given back
+		for i := 0; i < 3; i++ {
+			w = send(s, "a", `{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": ["foo"], "id": 2}`)
+			assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		}
+
+		for i := 0; i < 2; i++ {
+			w = send(s, "a", `{"jsonrpc": "2.0", "method": "mock_methodEmptyArgs", "id": 3}`)
+			assert.Equal(t, http.StatusOK, w.Code)
+		}
+
+		w = send(s, "a", `{"jsonrpc": "2.0", "method": "mock_methodEmptyArgs", "id": 4}`)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	})
+
+	t.Run("Each call of a batch counts", func(t *testing.T) {
+		// Calls run in order so the last one is rejected
+		s := newServer(t).SetBatchMode(BatchSequential)
+
+		w := send(s, "a", `[
+			{"jsonrpc": "2.0", "method": "mock_methodEmptyArgs", "id": 1},
+			{"jsonrpc": "2.0", "method": "mock_methodEmptyArgs", "id": 2},
+			{"jsonrpc": "2.0", "method": "mock_methodEmptyArgs", "id": 3},
+			{"jsonrpc": "2.0", "method": "mock_methodEmptyArgs", "id": 4}
+		]`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `[{"jsonrpc":"2.0","result":"foo","id":1},{"jsonrpc":"2.0","result":"foo","id":2},{"jsonrpc":"2.0","result":"foo","id":3},{"jsonrpc":"2.0","error":{"code":-32003,"message":"Too many requests","data":{"message":"rate limit exceeded","retryAfter":60}},"id":4}]`, w.Body.String())
+
+		w = send(s, "a", `{"jsonrpc": "2.0", "method": "mock_methodEmptyArgs", "id": 5}`)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	})
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"path"
+
+	"github.com/TomChv/jsonrpc2/redact"
+)
+
+// SetPayloadLogging add the params and result of calls to their logs and
+// traces, once redacted, see AddRedaction.
+// By default, payloads are neither logged nor traced.
+func (s *JsonRPC2) SetPayloadLogging(enabled bool) *JsonRPC2 {
+	s.payloadLog = enabled
+	return s
+}
+
+// AddRedaction hide the values selected by paths from the logs and traces
+// of the calls of the methods matching pattern, when payload logging is
+// enabled.
+// Paths are JSON paths rooted at params or result, see redact.Path.
+//
+// Fields of params and results tagged `rpc:"secret"` are always redacted.
+func (s *JsonRPC2) AddRedaction(pattern string, paths ...string) error {
+	if pattern == "" {
+		return ErrEmptyMethodName
+	}
+
+	if _, err := path.Match(pattern, ""); err != nil {
+		return ErrInvalidPattern
+	}
+
+	return s.redactor.Add(pattern, paths...)
+}
+
+// redactCall return the params and result of a call once redacted, they are
+// empty if the call has none.
+// Rules match the name of the called procedure as registered, so the
+// casing of the method does not escape them.
+func (s *JsonRPC2) redactCall(c *call, res *Response) (params, result string) {
+	if c == nil {
+		return "", ""
+	}
+
+	paths := s.redactionPaths(c)
+	if raw, ok := c.Params.(json.RawMessage); ok && len(raw) > 0 {
+		params = string(redact.Apply(redact.RootParams, raw, paths))
+	}
+	if res != nil && res.Error == nil && res.Result != nil {
+		result = string(redact.Value(redact.RootResult, res.Result, paths))
+	}
+
+	return params, result
+}
+
+// RedactExchange return copies of the URL and raw body of a request and of
+// its response where params and results are redacted as in the logs, see
+// AddRedaction. Results are matched to their call by ID.
+//
+// Params sent in the query of GET requests are redacted and encoded again
+// in base64. URLs and bodies that are not JSON-RPC messages are returned as
+// they are.
+func (s *JsonRPC2) RedactExchange(uri string, request, response []byte) (string, []byte, []byte) {
+	calls := make(map[string][]redact.Path)
+	redactRequest := func(msg map[string]json.RawMessage) bool {
+		var method string
+		if err := json.Unmarshal(msg["method"], &method); err != nil {
+			return false
+		}
+
+		paths := s.redactionPaths(s.resolve(&Request{Method: method}))
+		if id, ok := msg["id"]; ok {
+			calls[messageID(id)] = append(calls[messageID(id)], paths...)
+		}
+		return redactMember(msg, "params", redact.RootParams, paths)
+	}
+
+	uri = redactQuery(uri, redactRequest)
+	request = redactMessages(request, redactRequest)
+	response = redactMessages(response, func(msg map[string]json.RawMessage) bool {
+		return redactMember(msg, "result", redact.RootResult, calls[messageID(msg["id"])])
+	})
+
+	return uri, request, response
+}
+
+// redactQuery call redact with the call held by the query of uri, as sent
+// with GET. The params of the query are replaced only if redact changed
+// them.
+func redactQuery(uri string, redact func(msg map[string]json.RawMessage) bool) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+
+	query := u.Query()
+	if query.Get("method") == "" || query.Get("params") == "" {
+		return uri
+	}
+
+	body, err := queryRequest(query)
+	if err != nil {
+		return uri
+	}
+
+	var msg map[string]json.RawMessage
+	if err := json.Unmarshal(body, &msg); err != nil || !redact(msg) {
+		return uri
+	}
+
+	query.Set("params", base64.StdEncoding.EncodeToString(msg["params"]))
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// redactionPaths return the paths redacted in c.
+// Rules match the name of the called procedure as registered, so the
+// casing of the method does not escape them.
+func (s *JsonRPC2) redactionPaths(c *call) []redact.Path {
+	paths := s.redactor.Paths(c.name)
+	if c.procedure != nil {
+		paths = append(paths, c.procedure.Secrets...)
+	}
+	return paths
+}
+
+// redactMessages call redact with each message of a raw message or batch of
+// messages, data is encoded again only if redact changed a message
+func redactMessages(data []byte, redact func(msg map[string]json.RawMessage) bool) []byte {
+	var batch []map[string]json.RawMessage
+	isBatch := json.Unmarshal(data, &batch) == nil
+	if !isBatch {
+		var msg map[string]json.RawMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return data
+		}
+		batch = append(batch, msg)
+	}
+
+	changed := false
+	for _, msg := range batch {
+		if msg != nil && redact(msg) {
+			changed = true
+		}
+	}
+	if !changed {
+		return data
+	}
+
+	var res []byte
+	var err error
+	if isBatch {
+		res, err = json.Marshal(batch)
+	} else {
+		res, err = json.Marshal(batch[0])
+	}
+	if err != nil {
+		return data
+	}
+	return res
+}
+
+// redactMember apply the paths rooted at root to a member of msg, it returns
+// true if the member changed
+func redactMember(msg map[string]json.RawMessage, key, root string, paths []redact.Path) bool {
+	value, ok := msg[key]
+	if !ok {
+		return false
+	}
+
+	redacted := redact.Apply(root, value, paths)
+	msg[key] = redacted
+	return !bytes.Equal(value, redacted)
+}
+
+// messageID return the key of a raw request ID, formatting is ignored
+func messageID(id json.RawMessage) string {
+	var b bytes.Buffer
+	if err := json.Compact(&b, id); err != nil {
+		return string(id)
+	}
+	return b.String()
+}
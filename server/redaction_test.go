@@ -0,0 +1,228 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/TomChv/jsonrpc2/redact"
+	"github.com/TomChv/jsonrpc2/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockLoginCredentials struct {
+	User     string `json:"user"`
+	Password string `json:"password" rpc:"secret"`
+}
+
+type mockLoginSession struct {
+	User  string `json:"user"`
+	Token string `json:"token"`
+}
+
+type mockLoginService struct{}
+
+func (ms *mockLoginService) Login(c mockLoginCredentials) (*mockLoginSession, error) {
+	return &mockLoginSession{User: c.User, Token: "t0k3n"}, nil
+}
+
+func TestJsonRPC2_AddRedaction(t *testing.T) {
+	s := New(context.TODO())
+
+	assert.Equal(t, ErrEmptyMethodName, s.AddRedaction(""))
+	assert.Equal(t, ErrInvalidPattern, s.AddRedaction("auth_[", "params.password"))
+	assert.Equal(t, redact.ErrInvalidPath, s.AddRedaction("auth_*", "password"))
+	assert.Nil(t, s.AddRedaction("auth_*", "result.token"))
+}
+
+func TestJsonRPC2_Redaction(t *testing.T) {
+	testCases := []struct {
+		name           string
+		body           string
+		expectedParams string
+	}{
+		{
+			name:           "Object params",
+			body:           `{"jsonrpc": "2.0", "method": "auth_login", "params": {"user": "bob", "password": "secret"}, "id": 1}`,
+			expectedParams: `{"password":"[REDACTED]","user":"bob"}`,
+		},
+		{
+			name:           "Positional params",
+			body:           `{"jsonrpc": "2.0", "method": "auth_login", "params": [{"user": "bob", "password": "secret"}], "id": 1}`,
+			expectedParams: `[{"password":"[REDACTED]","user":"bob"}]`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &mockLogger{}
+			recorder := trace.NewRecorder()
+
+			s := New(context.TODO()).SetLogger(l).SetAccessLog(true).SetPayloadLogging(true).SetTracer(recorder)
+			assert.Nil(t, s.Register("auth", &mockLoginService{}))
+			assert.Nil(t, s.AddRedaction("auth_*", "result.token"))
+
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(tt.body)))
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, req)
+
+			// Responses are not redacted
+			assert.Contains(t, w.Body.String(), `"token":"t0k3n"`)
+
+			expectedResult := `{"token":"[REDACTED]","user":"bob"}`
+
+			assert.Len(t, l.entries, 1)
+			assert.Equal(t, tt.expectedParams, l.entries[0].fields[FieldParams])
+			assert.Equal(t, expectedResult, l.entries[0].fields[FieldResult])
+
+			calls := 0
+			for _, span := range recorder.Spans() {
+				if span.Name != "jsonrpc.call" {
+					continue
+				}
+				calls++
+				assert.Equal(t, tt.expectedParams, span.Attributes[trace.AttributeParams])
+				assert.Equal(t, expectedResult, span.Attributes[trace.AttributeResult])
+			}
+			assert.Equal(t, 1, calls)
+		})
+	}
+}
+
+func TestJsonRPC2_RedactionFailedCall(t *testing.T) {
+	l := &mockLogger{}
+	s := New(context.TODO()).SetLogger(l).SetPayloadLogging(true)
+	assert.Nil(t, s.AddRedaction("auth_*", "params.password"))
+
+	body := `{"jsonrpc": "2.0", "method": "auth_unknown", "params": {"password": "secret"}, "id": 1}`
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(body)))
+	s.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Len(t, l.entries, 1)
+	assert.Equal(t, `{"password":"[REDACTED]"}`, l.entries[0].fields[FieldParams])
+	assert.Nil(t, l.entries[0].fields[FieldResult])
+}
+
+func TestJsonRPC2_RedactionMethodCasing(t *testing.T) {
+	l := &mockLogger{}
+	s := New(context.TODO()).SetLogger(l).SetAccessLog(true).SetPayloadLogging(true)
+	assert.Nil(t, s.Register("auth", &mockLoginService{}))
+	assert.Nil(t, s.AddRedaction("auth_login", "params.user", "result.user"))
+
+	body := `{"jsonrpc": "2.0", "method": "auth_Login", "params": {"user": "bob", "password": "secret"}, "id": 1}`
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(body)))
+	s.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Len(t, l.entries, 1)
+	assert.Equal(t, `{"password":"[REDACTED]","user":"[REDACTED]"}`, l.entries[0].fields[FieldParams])
+	assert.Equal(t, `{"token":"t0k3n","user":"[REDACTED]"}`, l.entries[0].fields[FieldResult])
+}
+
+func TestJsonRPC2_PayloadLoggingDisabled(t *testing.T) {
+	l := &mockLogger{}
+	recorder := trace.NewRecorder()
+	s := New(context.TODO()).SetLogger(l).SetAccessLog(true).SetTracer(recorder)
+	assert.Nil(t, s.Register("auth", &mockLoginService{}))
+
+	body := `{"jsonrpc": "2.0", "method": "auth_login", "params": {"user": "bob", "password": "secret"}, "id": 1}`
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(body)))
+	s.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Len(t, l.entries, 1)
+	assert.NotContains(t, l.entries[0].fields, FieldParams)
+	assert.NotContains(t, l.entries[0].fields, FieldResult)
+
+	for _, span := range recorder.Spans() {
+		assert.NotContains(t, span.Attributes, trace.AttributeParams)
+		assert.NotContains(t, span.Attributes, trace.AttributeResult)
+	}
+}
+
+func TestJsonRPC2_RedactExchange(t *testing.T) {
+	s := New(context.TODO())
+	assert.Nil(t, s.Register("auth", &mockLoginService{}))
+	assert.Nil(t, s.Register("mock", &mockService{}))
+	assert.Nil(t, s.AddRedaction("auth_*", "result.token"))
+
+	encode := func(params string) string {
+		return url.QueryEscape(base64.StdEncoding.EncodeToString([]byte(params)))
+	}
+
+	testCases := []struct {
+		name             string
+		url              string
+		request          string
+		response         string
+		expectedURL      string
+		expectedRequest  string
+		expectedResponse string
+	}{
+		{
+			name:             "Call",
+			request:          `{"jsonrpc": "2.0", "method": "auth_Login", "params": {"user": "bob", "password": "secret"}, "id": 1}`,
+			response:         `{"jsonrpc":"2.0","result":{"user":"bob","token":"t0k3n"},"id":1}`,
+			expectedRequest:  `{"id":1,"jsonrpc":"2.0","method":"auth_Login","params":{"password":"[REDACTED]","user":"bob"}}`,
+			expectedResponse: `{"id":1,"jsonrpc":"2.0","result":{"token":"[REDACTED]","user":"bob"}}`,
+		},
+		{
+			name:             "Batch",
+			request:          `[{"jsonrpc": "2.0", "method": "mock_methodEmptyArgs", "id": "a"}, {"jsonrpc": "2.0", "method": "auth_login", "params": [{"password": "secret"}], "id": "b"}]`,
+			response:         `[{"jsonrpc":"2.0","result":{"token":"t0k3n"},"id":"b"},{"jsonrpc":"2.0","result":{"token":"foo"},"id":"a"}]`,
+			expectedRequest:  `[{"id":"a","jsonrpc":"2.0","method":"mock_methodEmptyArgs"},{"id":"b","jsonrpc":"2.0","method":"auth_login","params":[{"password":"[REDACTED]"}]}]`,
+			expectedResponse: `[{"id":"b","jsonrpc":"2.0","result":{"token":"[REDACTED]"}},{"id":"a","jsonrpc":"2.0","result":{"token":"foo"}}]`,
+		},
+		{
+			name:             "Nothing to redact",
+			request:          `{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": ["foo"], "id": 1}`,
+			response:         `{"jsonrpc":"2.0","result":"foo","id":1}`,
+			expectedRequest:  `{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": ["foo"], "id": 1}`,
+			expectedResponse: `{"jsonrpc":"2.0","result":"foo","id":1}`,
+		},
+		{
+			name:             "Invalid JSON",
+			request:          `{"jsonrpc": "2.0", "method": "auth_login"`,
+			response:         `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error"},"id":null}`,
+			expectedRequest:  `{"jsonrpc": "2.0", "method": "auth_login"`,
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error"},"id":null}`,
+		},
+		{
+			name:             "GET call",
+			url:              "/rpc?method=auth_login&params=" + encode(`{"user": "bob", "password": "secret"}`) + "&id=1",
+			response:         `{"jsonrpc":"2.0","result":{"user":"bob","token":"t0k3n"},"id":1}`,
+			expectedURL:      "/rpc?id=1&method=auth_login&params=" + encode(`{"password":"[REDACTED]","user":"bob"}`),
+			expectedResponse: `{"id":1,"jsonrpc":"2.0","result":{"token":"[REDACTED]","user":"bob"}}`,
+		},
+		{
+			name:             "GET call with plain JSON params",
+			url:              "/rpc?method=auth_login&params=" + url.QueryEscape(`[{"password": "secret"}]`),
+			response:         `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params"},"id":null}`,
+			expectedURL:      "/rpc?method=auth_login&params=" + encode(`[{"password":"[REDACTED]"}]`),
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params"},"id":null}`,
+		},
+		{
+			name:             "GET call with nothing to redact",
+			url:              "/rpc?method=mock_methodWithArgString&params=" + encode(`["foo"]`) + "&id=1",
+			response:         `{"jsonrpc":"2.0","result":"foo","id":1}`,
+			expectedURL:      "/rpc?method=mock_methodWithArgString&params=" + encode(`["foo"]`) + "&id=1",
+			expectedResponse: `{"jsonrpc":"2.0","result":"foo","id":1}`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			uri, expectedURL := tt.url, tt.expectedURL
+			if uri == "" {
+				uri, expectedURL = "/", "/"
+			}
+
+			uri, request, response := s.RedactExchange(uri, []byte(tt.request), []byte(tt.response))
+			assert.Equal(t, expectedURL, uri)
+			assert.Equal(t, tt.expectedRequest, string(request))
+			assert.Equal(t, tt.expectedResponse, string(response))
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/TomChv/jsonrpc2/redact"
+	"github.com/TomChv/jsonrpc2/server/parser"
+)
+
+var (
+	ErrMissingReturnValue = errors.New("method must return a result or an error")
+	ErrUnsupportedType    = errors.New("type is not supported by JSON")
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Method holds the metadata of a registered procedure.
+//
+// Metadata are computed once at registration so calling a method does not
+// require any further type lookup.
+type Method struct {
+	// Service is the namespace the method is registered in
+	Service string
+
+	// Name is the Go name of the method
+	Name string
+
+	// Doc is the documentation of the method, see Describer
+	Doc string
+
+	// Audited is true if calls of the method are recorded in the audit log,
+	// see Auditor
+	Audited bool
+
+	// Args are the types of the arguments expected in params, it excludes
+	// the receiver and the context
+	Args []reflect.Type
+
+	// TakesContext is true if the first argument of the method is a
+	// context.Context
+	TakesContext bool
+
+	// Results are the types returned by the method, it excludes the error
+	Results []reflect.Type
+
+	// ReturnsError is true if the last returned value is an error
+	ReturnsError bool
+
+	// Secrets are the paths of the params and result fields tagged
+	// `rpc:"secret"`
+	Secrets []redact.Path
+
+	receiver reflect.Value
+	function reflect.Value
+	variadic bool
+	decoder  *parser.Decoder
+}
+
+// newMethod extract metadata from a method of the service
+func newMethod(service string, receiver reflect.Value, m reflect.Method) *Method {
+	ft := m.Func.Type()
+
+	method := &Method{
+		Service:  service,
+		Name:     m.Name,
+		receiver: receiver,
+		function: m.Func,
+		variadic: ft.IsVariadic(),
+	}
+
+	// Skip the receiver
+	for i := 1; i < ft.NumIn(); i++ {
+		if i == 1 && ft.In(i) == contextType {
+			method.TakesContext = true
+			continue
+		}
+		method.Args = append(method.Args, ft.In(i))
+	}
+
+	for i := 0; i < ft.NumOut(); i++ {
+		if i == ft.NumOut()-1 && ft.Out(i).Implements(errorType) {
+			method.ReturnsError = true
+			continue
+		}
+		method.Results = append(method.Results, ft.Out(i))
+	}
+
+	method.decoder = parser.NewDecoder(method.Args, method.variadic)
+	method.Secrets = method.secrets()
+	return method
+}
+
+// secrets compute the paths of the secret fields of the arguments and
+// results. Params may be positional, or an object decoded as the first
+// argument.
+func (m *Method) secrets() []redact.Path {
+	var paths []redact.Path
+
+	for i, arg := range m.Args {
+		if i == 0 {
+			paths = append(paths, redact.TypePaths(redact.RootParams, arg)...)
+		}
+
+		// Variadic params are gathered in the last argument
+		if m.variadic && i == len(m.Args)-1 {
+			paths = append(paths, redact.TypePaths(redact.RootParams+"[*]", arg.Elem())...)
+			continue
+		}
+		paths = append(paths, redact.TypePaths(fmt.Sprintf("%s[%d]", redact.RootParams, i), arg)...)
+	}
+
+	// Many results are sent as an array
+	for i, res := range m.Results {
+		if len(m.Results) == 1 {
+			paths = append(paths, redact.TypePaths(redact.RootResult, res)...)
+			continue
+		}
+		paths = append(paths, redact.TypePaths(fmt.Sprintf("%s[%d]", redact.RootResult, i), res)...)
+	}
+
+	return paths
+}
+
+// validate ensure that the method can be called through JSON-RPC :
+//   - it returns at least a result or an error
+//   - its arguments and results can be encoded in JSON
+func (m *Method) validate() error {
+	if len(m.Results) == 0 && !m.ReturnsError {
+		return ErrMissingReturnValue
+	}
+
+	types := make([]reflect.Type, 0, len(m.Args)+len(m.Results))
+	types = append(append(types, m.Args...), m.Results...)
+
+	for _, t := range types {
+		if !isJSONType(t) {
+			return fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+		}
+	}
+
+	return nil
+}
+
+// isJSONType return false if t can not be encoded or decoded in JSON
+func isJSONType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+		return false
+	default:
+		return true
+	}
+}
+
+// Decoder return the params decoder of the method
+func (m *Method) Decoder() *parser.Decoder {
+	return m.decoder
+}
+
+// Call the method with the given arguments.
+// The context is only given to the method if it takes one.
+//
+// Arguments must match Args, values returned by Decoder do.
+func (m *Method) Call(ctx context.Context, args []reflect.Value) []reflect.Value {
+	in := make([]reflect.Value, 0, len(args)+2)
+	in = append(in, m.receiver)
+	if m.TakesContext {
+		in = append(in, reflect.ValueOf(&ctx).Elem())
+	}
+	in = append(in, args...)
+
+	if m.variadic {
+		return m.function.CallSlice(in)
+	}
+	return m.function.Call(in)
+}
+
+// Unpack split values returned by Call into the result and the error of the
+// procedure.
+// Result depends on the method return shape :
+//   - error only -> nil
+//   - one result -> the result
+//   - many results -> an array of results
+func (m *Method) Unpack(ret []reflect.Value) (interface{}, error) {
+	if m.ReturnsError {
+		errValue := ret[len(ret)-1]
+		if !isNil(errValue) {
+			// nolint:forcetypeassert
+			return nil, errValue.Interface().(error)
+		}
+		ret = ret[:len(ret)-1]
+	}
+
+	switch len(ret) {
+	case 0:
+		return nil, nil
+	case 1:
+		return ret[0].Interface(), nil
+	default:
+		res := make([]interface{}, len(ret))
+		for i, r := range ret {
+			res[i] = r.Interface()
+		}
+		return res, nil
+	}
+}
+
+// isNil return true if the value is nil, including typed nil pointers
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
@@ -0,0 +1,188 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMethod_Call(t *testing.T) {
+	r := New()
+	assert.Nil(t, r.Register("mock", &mockService{}))
+
+	type ctxKey = string
+	ctx := context.WithValue(context.TODO(), ctxKey("foo"), "bar")
+
+	testCases := []struct {
+		name     string
+		method   string
+		params   string
+		expected interface{}
+	}{
+		{
+			name:     "Call with arguments",
+			method:   "Add",
+			params:   `[1, 2]`,
+			expected: 3,
+		},
+		{
+			name:     "Call with context",
+			method:   "WithContext",
+			params:   `["foo"]`,
+			expected: "bar",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := r.Method("mock", tt.method)
+			assert.Nil(t, err)
+
+			args, err := m.Decoder().Decode([]byte(tt.params))
+			assert.Nil(t, err)
+
+			ret := m.Call(ctx, args)
+			assert.Equal(t, tt.expected, ret[0].Interface())
+			assert.Nil(t, ret[1].Interface())
+		})
+	}
+}
+
+type mockShapeService struct{}
+
+func (ms *mockShapeService) ErrorOnly(fail bool) error {
+	if fail {
+		return errors.New("failed")
+	}
+	return nil
+}
+
+func (ms *mockShapeService) ResultOnly() string {
+	return "foo"
+}
+
+func (ms *mockShapeService) MultiValues() (string, int, error) {
+	return "foo", 4, nil
+}
+
+func (ms *mockShapeService) MultiValuesNoError() (string, int) {
+	return "foo", 4
+}
+
+func TestMethod_Unpack(t *testing.T) {
+	r := New()
+	assert.Nil(t, r.Register("mock", &mockShapeService{}))
+
+	testCases := []struct {
+		name           string
+		method         string
+		args           []reflect.Value
+		expectedResult interface{}
+		expectedError  error
+	}{
+		{
+			name:           "Error only : success",
+			method:         "ErrorOnly",
+			args:           []reflect.Value{reflect.ValueOf(false)},
+			expectedResult: nil,
+			expectedError:  nil,
+		},
+		{
+			name:           "Error only : failure",
+			method:         "ErrorOnly",
+			args:           []reflect.Value{reflect.ValueOf(true)},
+			expectedResult: nil,
+			expectedError:  errors.New("failed"),
+		},
+		{
+			name:           "Result only",
+			method:         "ResultOnly",
+			expectedResult: "foo",
+			expectedError:  nil,
+		},
+		{
+			name:           "Multi values",
+			method:         "MultiValues",
+			expectedResult: []interface{}{"foo", 4},
+			expectedError:  nil,
+		},
+		{
+			name:           "Multi values without error",
+			method:         "MultiValuesNoError",
+			expectedResult: []interface{}{"foo", 4},
+			expectedError:  nil,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := r.Method("mock", tt.method)
+			assert.Nil(t, err)
+
+			res, err := m.Unpack(m.Call(context.TODO(), tt.args))
+			assert.Equal(t, tt.expectedResult, res)
+			assert.Equal(t, tt.expectedError, err)
+		})
+	}
+}
+
+type mockCredentials struct {
+	User     string `json:"user"`
+	Password string `json:"password" rpc:"secret"`
+}
+
+type mockSecretService struct{}
+
+func (ms *mockSecretService) Login(ctx context.Context, c mockCredentials) (*mockCredentials, error) {
+	return &c, nil
+}
+
+func (ms *mockSecretService) Rotate(user string, c ...mockCredentials) (string, mockCredentials) {
+	return user, mockCredentials{}
+}
+
+func (ms *mockSecretService) Plain(user string) string {
+	return user
+}
+
+func TestMethod_Secrets(t *testing.T) {
+	r := New()
+	assert.Nil(t, r.Register("secret", &mockSecretService{}))
+
+	testCases := []struct {
+		name     string
+		method   string
+		expected []string
+	}{
+		{
+			name:     "Object or positional params",
+			method:   "Login",
+			expected: []string{"params.password", "params[0].password", "result.password"},
+		},
+		{
+			name:     "Variadic params and many results",
+			method:   "Rotate",
+			expected: []string{"params[*].password", "result[1].password"},
+		},
+		{
+			name:   "No secret",
+			method: "Plain",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := r.Method("secret", tt.method)
+			assert.Nil(t, err)
+
+			var secrets []string
+			for _, p := range m.Secrets {
+				secrets = append(secrets, p.String())
+			}
+			assert.Equal(t, tt.expected, secrets)
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+var (
+	ErrInvalidServiceType       = errors.New("service must be a pointer to struct")
+	ErrInvalidServiceProcedures = errors.New("services procedures does not match ServiceProcedure type")
+	ErrNonExistentService       = errors.New("service is not registered")
+	ErrNonExistentMethod        = errors.New("method is not registered")
+)
+
+// UnsupportedMethodError is reported when a method of a service is skipped
+// at registration because its signature is not supported
+type UnsupportedMethodError struct {
+	Service string
+	Method  string
+	Err     error
+}
+
+func (e *UnsupportedMethodError) Error() string {
+	return fmt.Sprintf("skip method %s of service %q: %v", e.Method, e.Service, e.Err)
+}
+
+func (e *UnsupportedMethodError) Unwrap() error {
+	return e.Err
+}
+
+// Describer may be implemented by a service to document its methods.
+//
+// Describe is called once per method at registration, it is not registered
+// as a procedure itself.
+type Describer interface {
+	Describe(method string) string
+}
+
+// Auditor may be implemented by a service to mark the methods whose calls
+// are recorded in the audit log, such as state-changing methods.
+//
+// Audited is called once per method at registration, it is not registered
+// as a procedure itself.
+type Auditor interface {
+	Audited(method string) bool
+}
+
+// Registry holds registered services along with their methods metadata
+type Registry struct {
+	// Warn is called with an UnsupportedMethodError for each skipped method
+	Warn func(err error)
+
+	services map[string]map[string]*Method
+	l        sync.RWMutex
+}
+
+// New create an empty Registry
+func New() *Registry {
+	return &Registry{
+		services: make(map[string]map[string]*Method),
+	}
+}
+
+// Register the exported methods of service under the given namespace.
+//
+// The service must be a pointer to struct.
+// Exported methods that can not be called through JSON-RPC are skipped and
+// reported to Warn, if none of them can be called the service is rejected.
+// Registering a namespace twice replaces the previous service.
+func (r *Registry) Register(namespace string, service interface{}) error {
+	st := reflect.TypeOf(service)
+	if st == nil || st.Kind() != reflect.Ptr || st.Elem().Kind() != reflect.Struct {
+		return ErrInvalidServiceType
+	}
+
+	describer, hasDoc := service.(Describer)
+	auditor, hasAudit := service.(Auditor)
+	receiver := reflect.ValueOf(service)
+
+	methods := make(map[string]*Method)
+	skipped := 0
+	for i := 0; i < st.NumMethod(); i++ {
+		m := st.Method(i)
+		if !m.IsExported() || (hasDoc && m.Name == "Describe") || (hasAudit && m.Name == "Audited") {
+			continue
+		}
+
+		method := newMethod(namespace, receiver, m)
+		if err := method.validate(); err != nil {
+			skipped++
+			r.warn(&UnsupportedMethodError{Service: namespace, Method: m.Name, Err: err})
+			continue
+		}
+
+		if hasDoc {
+			method.Doc = describer.Describe(m.Name)
+		}
+		if hasAudit {
+			method.Audited = auditor.Audited(m.Name)
+		}
+		methods[m.Name] = method
+	}
+
+	if skipped > 0 && len(methods) == 0 {
+		return ErrInvalidServiceProcedures
+	}
+
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	r.services[namespace] = methods
+	return nil
+}
+
+func (r *Registry) warn(err error) {
+	if r.Warn != nil {
+		r.Warn(err)
+	}
+}
+
+// Method return the metadata of a method registered in service
+func (r *Registry) Method(service, method string) (*Method, error) {
+	r.l.RLock()
+	defer r.l.RUnlock()
+
+	s, ok := r.services[service]
+	if !ok {
+		return nil, ErrNonExistentService
+	}
+
+	m, ok := s[method]
+	if !ok {
+		return nil, ErrNonExistentMethod
+	}
+
+	return m, nil
+}
+
+// Methods return every registered methods sorted by service and name
+func (r *Registry) Methods() []*Method {
+	r.l.RLock()
+	defer r.l.RUnlock()
+
+	var res []*Method
+	for _, s := range r.services {
+		for _, m := range s {
+			res = append(res, m)
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Service != res[j].Service {
+			return res[i].Service < res[j].Service
+		}
+		return res[i].Name < res[j].Name
+	})
+
+	return res
+}
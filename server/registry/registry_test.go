@@ -0,0 +1,230 @@
+package registry
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockService struct{}
+
+func (ms *mockService) Add(a, b int) (int, error) {
+	return a + b, nil
+}
+
+func (ms *mockService) WithContext(ctx context.Context, key string) (interface{}, error) {
+	return ctx.Value(key), nil
+}
+
+type mockDocumentedService struct{}
+
+func (ms *mockDocumentedService) Hello() (string, error) {
+	return "hello", nil
+}
+
+func (ms *mockDocumentedService) Describe(method string) string {
+	return method + " says hello"
+}
+
+func (ms *mockDocumentedService) Audited(method string) bool {
+	return method == "Hello"
+}
+
+type mockInvalidService struct{}
+
+func (ms *mockInvalidService) NoReturnType() {}
+
+type mockPartialService struct{}
+
+func (ms *mockPartialService) Valid() error {
+	return nil
+}
+
+func (ms *mockPartialService) NoReturnType() {}
+
+func (ms *mockPartialService) ChanArgument(c chan int) error {
+	return nil
+}
+
+func TestRegistry_Register(t *testing.T) {
+	testCases := []struct {
+		name          string
+		service       interface{}
+		expectedError error
+	}{
+		{
+			name:          "Invalid service : nil",
+			service:       nil,
+			expectedError: ErrInvalidServiceType,
+		},
+		{
+			name:          "Invalid service : struct",
+			service:       mockService{},
+			expectedError: ErrInvalidServiceType,
+		},
+		{
+			name:          "Invalid service : procedures",
+			service:       &mockInvalidService{},
+			expectedError: ErrInvalidServiceProcedures,
+		},
+		{
+			name:          "Valid service",
+			service:       &mockService{},
+			expectedError: nil,
+		},
+		{
+			name:          "Valid service : unsupported methods are skipped",
+			service:       &mockPartialService{},
+			expectedError: nil,
+		},
+		{
+			name:          "Valid service : documented",
+			service:       &mockDocumentedService{},
+			expectedError: nil,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New()
+			assert.Equal(t, tt.expectedError, r.Register("mock", tt.service))
+		})
+	}
+}
+
+func TestRegistry_Register_Warn(t *testing.T) {
+	var warnings []error
+
+	r := New()
+	r.Warn = func(err error) {
+		warnings = append(warnings, err)
+	}
+	assert.Nil(t, r.Register("mock", &mockPartialService{}))
+
+	assert.Len(t, warnings, 2)
+	var unsupported *UnsupportedMethodError
+	assert.ErrorAs(t, warnings[0], &unsupported)
+	assert.Equal(t, "ChanArgument", unsupported.Method)
+	assert.ErrorIs(t, warnings[0], ErrUnsupportedType)
+	assert.ErrorAs(t, warnings[1], &unsupported)
+	assert.Equal(t, "NoReturnType", unsupported.Method)
+	assert.ErrorIs(t, warnings[1], ErrMissingReturnValue)
+
+	_, err := r.Method("mock", "Valid")
+	assert.Nil(t, err)
+	_, err = r.Method("mock", "NoReturnType")
+	assert.Equal(t, ErrNonExistentMethod, err)
+}
+
+func TestRegistry_Method(t *testing.T) {
+	r := New()
+	assert.Nil(t, r.Register("mock", &mockService{}))
+	assert.Nil(t, r.Register("doc", &mockDocumentedService{}))
+
+	testCases := []struct {
+		name          string
+		service       string
+		method        string
+		expected      *Method
+		expectedError error
+	}{
+		{
+			name:          "Unknown service",
+			service:       "unknown",
+			method:        "Add",
+			expectedError: ErrNonExistentService,
+		},
+		{
+			name:          "Unknown method",
+			service:       "mock",
+			method:        "Sub",
+			expectedError: ErrNonExistentMethod,
+		},
+		{
+			name:    "Method with arguments",
+			service: "mock",
+			method:  "Add",
+			expected: &Method{
+				Service:      "mock",
+				Name:         "Add",
+				Args:         []reflect.Type{reflect.TypeOf(0), reflect.TypeOf(0)},
+				Results:      []reflect.Type{reflect.TypeOf(0)},
+				ReturnsError: true,
+			},
+		},
+		{
+			name:    "Method with context",
+			service: "mock",
+			method:  "WithContext",
+			expected: &Method{
+				Service:      "mock",
+				Name:         "WithContext",
+				Args:         []reflect.Type{reflect.TypeOf("")},
+				TakesContext: true,
+				Results:      []reflect.Type{reflect.TypeOf((*interface{})(nil)).Elem()},
+				ReturnsError: true,
+			},
+		},
+		{
+			name:    "Documented method",
+			service: "doc",
+			method:  "Hello",
+			expected: &Method{
+				Service:      "doc",
+				Name:         "Hello",
+				Doc:          "Hello says hello",
+				Audited:      true,
+				Results:      []reflect.Type{reflect.TypeOf("")},
+				ReturnsError: true,
+			},
+		},
+		{
+			name:          "Documentation hook is not a method",
+			service:       "doc",
+			method:        "Describe",
+			expectedError: ErrNonExistentMethod,
+		},
+		{
+			name:          "Audit hook is not a method",
+			service:       "doc",
+			method:        "Audited",
+			expectedError: ErrNonExistentMethod,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := r.Method(tt.service, tt.method)
+			assert.Equal(t, tt.expectedError, err)
+
+			if tt.expected == nil {
+				assert.Nil(t, m)
+				return
+			}
+
+			assert.Equal(t, tt.expected.Service, m.Service)
+			assert.Equal(t, tt.expected.Name, m.Name)
+			assert.Equal(t, tt.expected.Doc, m.Doc)
+			assert.Equal(t, tt.expected.Audited, m.Audited)
+			assert.Equal(t, tt.expected.Args, m.Args)
+			assert.Equal(t, tt.expected.TakesContext, m.TakesContext)
+			assert.Equal(t, tt.expected.Results, m.Results)
+			assert.Equal(t, tt.expected.ReturnsError, m.ReturnsError)
+		})
+	}
+}
+
+func TestRegistry_Methods(t *testing.T) {
+	r := New()
+	assert.Nil(t, r.Register("mock", &mockService{}))
+	assert.Nil(t, r.Register("doc", &mockDocumentedService{}))
+
+	var names []string
+	for _, m := range r.Methods() {
+		names = append(names, m.Service+"_"+m.Name)
+	}
+
+	assert.Equal(t, []string{"doc_Hello", "mock_Add", "mock_WithContext"}, names)
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/TomChv/jsonrpc2/common"
+	"github.com/TomChv/jsonrpc2/server/validator"
 )
 
 type Response common.Response
@@ -46,6 +47,10 @@ func (r *Response) Send(w http.ResponseWriter) error {
 		return err
 	}
 
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", validator.ContentTypeJSON)
+	}
+
 	_, err = w.Write(data)
 	if err != nil {
 		return err
@@ -2,147 +2,203 @@ package server
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"log"
 	"net/http"
 	"sync"
+	"time"
 
-	"github.com/PtitLuca/go-dispatcher/dispatcher"
 	"github.com/TomChv/jsonrpc2/common"
+	"github.com/TomChv/jsonrpc2/redact"
 	"github.com/TomChv/jsonrpc2/server/parser"
+	"github.com/TomChv/jsonrpc2/server/registry"
 	"github.com/TomChv/jsonrpc2/server/validator"
+	"github.com/TomChv/jsonrpc2/trace"
 )
 
 type Request = common.Request
 type RpcError = common.RpcError
 
+var ErrInvalidServiceProcedures = registry.ErrInvalidServiceProcedures
+
 // JsonRPC2 is a simple HTTP server that follow JSON RPC 2.0 specification
 // See https://www.jsonrpc.org/specification for more information
 type JsonRPC2 struct {
 	ctx context.Context
-	d   *dispatcher.Dispatcher
+	r   *registry.Registry
+
+	handlers map[string]Handler
+	routes   []route
+	fallback Handler
+	l        sync.RWMutex
+
+	batchMode    BatchMode
+	batchWorkers int
+
+	limits Limits
+	strict bool
+	http   HTTPOptions
+	path   string
+
+	middlewares []Middleware
+	entry       http.Handler
+
+	authenticators []Authenticator
+	policies       []Policy
+	discovery      bool
+
+	rateLimits     []RateLimit
+	rateLimitStore RateLimitStore
+
+	timeout        time.Duration
+	methodTimeouts []methodTimeout
+
+	limiter        *limiter
+	methodLimiters []methodLimiter
+
+	metrics *metrics
+	tracer  trace.Tracer
+
+	logger         Logger
+	accessLog      bool
+	clientWarnings *sampler
+	redactor       *redact.Redactor
+	payloadLog     bool
+	auditSink      AuditSink
 }
 
 // New create a JSON RPC 2.0 server
 func New(ctx context.Context) *JsonRPC2 {
-	return &JsonRPC2{
-		ctx: ctx,
-		d:   dispatcher.New(),
+	s := &JsonRPC2{
+		ctx:      ctx,
+		r:        registry.New(),
+		handlers: make(map[string]Handler),
+		path:     "/",
+
+		rateLimitStore: NewMemoryRateLimitStore(),
+		logger:         NewStdLogger(nil),
+		clientWarnings: newSampler(clientWarningInterval),
+		redactor:       redact.New(),
 	}
+	s.r.Warn = func(err error) {
+		s.logger.Warn("skip procedure", Field{FieldError, err})
+	}
+	s.entry = http.HandlerFunc(s.serve)
+
+	return s
 }
 
 // Register a new RPC
 //
-// Not matter what your service's procedures takes as parameters they
-// must return (*Response, *RpcError)
+// Procedures may return :
+//   - error : the result is null on success
+//   - T or (T, error) : T is the result
+//   - (T1, T2, ..., error) : results are sent as an array
+//
+// Exported methods with another shape are skipped with a warning.
+// A procedure may take a context.Context as first parameter, it receives the
+// context of the call.
 func (s *JsonRPC2) Register(namespace string, service interface{}) error {
-	if !validateService(service) {
-		return ErrInvalidServiceProcedures
-	}
-
-	if err := s.d.Register(namespace, service); err != nil {
+	if err := s.checkReserved(namespace); err != nil {
 		return err
 	}
-	return nil
+
+	return s.r.Register(namespace, service)
 }
 
 // Implement HTTP interface to listen and response to incoming HTTP request
 func (s *JsonRPC2) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if err := validator.HTTPRequest(r); err != nil {
-		_ = NewResponse(nil).SetError(InvalidRequestError(err)).Send(w)
+	s.entry.ServeHTTP(w, r)
+}
+
+// serve handle a HTTP request once it went through middlewares
+func (s *JsonRPC2) serve(w http.ResponseWriter, r *http.Request) {
+	ctx, span := s.startSpan(trace.Extract(withHTTPRequest(r), r.Header), "jsonrpc.request")
+	span.SetAttribute(trace.AttributeHTTPPath, r.URL.Path)
+	defer span.End()
+
+	if r.Method == http.MethodGet && len(s.http.GETMethods) > 0 {
+		s.serveGET(ctx, w, r)
 		return
 	}
 
-	isBatch, err := validator.IsBatchRequest(r)
-	if err != nil {
-		_ = NewResponse(nil).SetError(ParsingError(err)).Send(w)
+	if err := validator.HTTPMethod(r); err != nil {
+		w.Header().Set("Allow", s.allowedMethods())
+		s.replyHTTPError(w, r, http.StatusMethodNotAllowed, InvalidRequestError(err))
 		return
 	}
 
-	body, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		_ = NewResponse(nil).SetError(ParsingError(err)).Send(w)
+	if err := s.checkPath(r); err != nil {
+		s.replyError(w, r, InvalidRequestError(err))
 		return
 	}
 
-	if !isBatch {
-		req, err := parser.Request(body)
-		if err != nil {
-			res := NewResponse(nil).SetError(InvalidRequestError(err))
-			if req != nil && req.ID != nil {
-				res.SetID(req.ID)
-			}
-
-			_ = res.Send(w)
+	if s.http.RequireContentType {
+		if err := validator.ContentType(r); err != nil {
+			s.replyHTTPError(w, r, http.StatusUnsupportedMediaType, InvalidRequestError(err))
 			return
 		}
-
-		r := s.handle(req)
-		if r.ID != nil {
-			_ = r.Send(w)
-		}
-		return
 	}
 
-	reqs, err := parser.Batch(body)
+	body, err := s.readBody(r.Body)
 	if err != nil {
-		if errors.Is(err, parser.ErrEmptyBatch) {
-			_ = NewResponse(nil).SetError(InvalidRequestError(err)).Send(w)
+		if errors.Is(err, validator.ErrBodyTooLarge) {
+			s.replyError(w, r, InvalidRequestError(err))
 		} else {
-			_ = NewResponse(nil).SetError(ParsingError(err)).Send(w)
+			s.replyError(w, r, ParsingError(err))
 		}
 		return
 	}
 
-	batchRes := &Batch{}
-
-	// Handle concurrency
-	var wg sync.WaitGroup
-
-	for _, rawReq := range reqs {
-		wg.Add(1)
-
-		go func(rawR []byte) {
-			defer wg.Done()
+	ctx, ok := s.authenticate(ctx, w, r, body)
+	if !ok {
+		return
+	}
 
-			req := common.Request{}
-			var r *Response
+	if err := s.checkDepth(body); err != nil {
+		s.replyError(w, r, InvalidRequestError(err))
+		return
+	}
 
-			err := json.Unmarshal(rawR, &req)
-			if err != nil {
-				r = NewResponse(nil).SetError(InvalidRequestError(err))
-				batchRes.Append(r)
-				return
-			}
+	isBatch, err := validator.IsBatch(body)
+	if err != nil {
+		s.replyError(w, r, ParsingError(err))
+		return
+	}
 
-			if req.JsonRpc == "" {
-				r = NewResponse(nil).SetError(InvalidRequestError(validator.ErrInvalidJsonVersion))
-				batchRes.Append(r)
-				return
-			}
+	if !isBatch {
+		res, md := s.handleCall(ctx, body)
+		writeHeaders(w, md)
+		s.reply(w, r, res)
+		return
+	}
 
-			if err := validator.JsonRPCRequest(&req); err != nil {
-				r = NewResponse(nil).SetError(InvalidRequestError(err))
-				if req.ID != nil {
-					r.SetID(req.ID)
-				}
-			} else {
-				r = s.handle(&req)
-			}
+	reqs, err := parser.Batch(body)
+	if err != nil {
+		if errors.Is(err, parser.ErrEmptyBatch) {
+			s.replyError(w, r, InvalidRequestError(err))
+		} else {
+			s.replyError(w, r, ParsingError(err))
+		}
+		return
+	}
 
-			if r.ID == nil {
-				return
-			}
+	if err := s.checkBatchSize(len(reqs)); err != nil {
+		s.replyError(w, r, InvalidRequestError(err))
+		return
+	}
 
-			batchRes.Append(r)
-		}(rawReq)
+	if s.metrics != nil {
+		s.metrics.observeBatch(len(reqs))
 	}
 
-	wg.Wait()
-	_ = batchRes.Send(w)
+	batchCtx, batchSpan := s.startSpan(ctx, "jsonrpc.batch")
+	batchSpan.SetAttribute(trace.AttributeBatchSize, len(reqs))
+	batch, md := s.handleBatch(batchCtx, reqs)
+	batchSpan.End()
+
+	writeHeaders(w, md)
+	s.replyBatch(w, r, batch)
 }
 
 // Run start JSON RPC 2.0 server
@@ -153,12 +209,12 @@ func (s *JsonRPC2) Run(port string) error {
 	go func() {
 		err := http.ListenAndServe(addr, s)
 		if err != nil {
-			log.Println(err)
+			s.logger.Error("server stopped", Field{FieldError, err})
 			cancel()
 		}
 	}()
 
-	log.Println(fmt.Sprintf("JSON RPC 2.0 server listening on http://0.0.0.0:%s", addr))
+	s.logger.Info("JSON RPC 2.0 server listening", Field{FieldAddr, fmt.Sprintf("http://0.0.0.0%s", addr)})
 
 	select {
 	case <-s.ctx.Done():
@@ -4,15 +4,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
-	"github.com/PtitLuca/go-dispatcher/dispatcher"
 	"github.com/TomChv/jsonrpc2/client"
 	"github.com/TomChv/jsonrpc2/common"
+	"github.com/TomChv/jsonrpc2/server/parser"
+	"github.com/TomChv/jsonrpc2/server/registry"
 	"github.com/TomChv/jsonrpc2/server/validator"
 	"github.com/stretchr/testify/assert"
 )
@@ -26,12 +29,20 @@ type mockInvalidService struct{}
 
 func (ms *mockInvalidService) MethodInvalidNoReturnType() {}
 
-type mockInvalidService2 struct{}
+type mockResultOnlyService struct{}
 
-func (ms *mockInvalidService2) MethodInvalidIncompleteReturnType() interface{} {
+func (ms *mockResultOnlyService) MethodResultOnly() interface{} {
 	return nil
 }
 
+type mockPartialService struct{}
+
+func (ms *mockPartialService) MethodValid() (string, error) {
+	return "foo", nil
+}
+
+func (ms *mockPartialService) MethodHelper(c chan int) {}
+
 type mockService struct{}
 
 func (ms mockService) MethodEmptyArgs() (interface{}, error) {
@@ -62,6 +73,32 @@ func (ms mockService) MethodWithSleep(sec int64) (string, error) {
 	return "slept well", nil
 }
 
+func (ms mockService) MethodErrorOnly(fail bool) error {
+	if fail {
+		return errors.New("failed")
+	}
+	return nil
+}
+
+func (ms mockService) MethodResultOnly(str string) string {
+	return str
+}
+
+func (ms mockService) MethodMultiValues(str string, num int) (string, int, error) {
+	return str, num, nil
+}
+
+func (ms mockService) MethodWithOptionalArg(str string, num *int) (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"str": str,
+		"num": num,
+	}, nil
+}
+
+func (ms mockService) MethodWithVariadicArgs(sep string, str ...string) (string, error) {
+	return strings.Join(str, sep), nil
+}
+
 type FakeStruct struct {
 	Id     int
 	Field1 bool
@@ -93,11 +130,18 @@ func TestJsonRPC2_Register(t *testing.T) {
 			expectedError: ErrInvalidServiceProcedures,
 		},
 		{
-			name:          "Invalid service method : Incomplete return type",
+			name:          "Valid service method : Result only return type",
 			serviceName:   "mock",
-			service:       &mockInvalidService2{},
-			success:       false,
-			expectedError: ErrInvalidServiceProcedures,
+			service:       &mockResultOnlyService{},
+			success:       true,
+			expectedError: nil,
+		},
+		{
+			name:          "Valid service : Unsupported helper method is skipped",
+			serviceName:   "mock",
+			service:       &mockPartialService{},
+			success:       true,
+			expectedError: nil,
 		},
 		{
 			name:          "Valid service",
@@ -182,6 +226,78 @@ func TestJsonRPC2_ServeHTTP_Single(t *testing.T) {
 				},
 			}),
 		},
+		{
+			name:             "call MethodErrorOnly",
+			success:          true,
+			req:              client.NewRequest().SetID("fake_id").SetMethod("mock_methodErrorOnly").SetParams([]bool{false}),
+			expectedResponse: NewResponse("fake_id"),
+		},
+		{
+			name:             "call MethodErrorOnly with error",
+			success:          false,
+			req:              client.NewRequest().SetID("fake_id").SetMethod("mock_methodErrorOnly").SetParams([]bool{true}),
+			expectedResponse: NewResponse("fake_id").SetError(InternalError(errors.New("failed"))),
+		},
+		{
+			name:             "call MethodResultOnly",
+			success:          true,
+			req:              client.NewRequest().SetID("fake_id").SetMethod("mock_methodResultOnly").SetParams([]string{"bar"}),
+			expectedResponse: NewResponse("fake_id").SetResult("bar"),
+		},
+		{
+			name:             "call MethodMultiValues",
+			success:          true,
+			req:              client.NewRequest().SetID("fake_id").SetMethod("mock_methodMultiValues").SetParams([]interface{}{"bar", 4}),
+			expectedResponse: NewResponse("fake_id").SetResult([]interface{}{"bar", float64(4)}),
+		},
+		{
+			name:    "call MethodWithOptionalArg",
+			success: true,
+			req:     client.NewRequest().SetID("fake_id").SetMethod("mock_methodWithOptionalArg").SetParams([]interface{}{"foo", 4}),
+			expectedResponse: NewResponse("fake_id").SetResult(map[string]interface{}{
+				"str": "foo",
+				"num": float64(4),
+			}),
+		},
+		{
+			name:    "call MethodWithOptionalArg without optional",
+			success: true,
+			req:     client.NewRequest().SetID("fake_id").SetMethod("mock_methodWithOptionalArg").SetParams([]interface{}{"foo"}),
+			expectedResponse: NewResponse("fake_id").SetResult(map[string]interface{}{
+				"str": "foo",
+				"num": nil,
+			}),
+		},
+		{
+			name:             "call MethodWithVariadicArgs",
+			success:          true,
+			req:              client.NewRequest().SetID("fake_id").SetMethod("mock_methodWithVariadicArgs").SetParams([]string{"-", "foo", "bar", "baz"}),
+			expectedResponse: NewResponse("fake_id").SetResult("foo-bar-baz"),
+		},
+		{
+			name:             "call MethodWithArgs with too many params",
+			success:          false,
+			req:              client.NewRequest().SetID("fake_id").SetMethod("mock_methodWithArgs").SetParams([]interface{}{"foo", 25, true}),
+			expectedResponse: NewResponse("fake_id").SetError(InvalidParamsError(parser.ErrInvalidArgsCount)),
+		},
+		{
+			name:             "call MethodWithArgs with missing params",
+			success:          false,
+			req:              client.NewRequest().SetID("fake_id").SetMethod("mock_methodWithArgs").SetParams([]interface{}{"foo"}),
+			expectedResponse: NewResponse("fake_id").SetError(InvalidParamsError(parser.ErrInvalidArgsCount)),
+		},
+		{
+			name:             "call MethodEmptyArgs with params",
+			success:          false,
+			req:              client.NewRequest().SetID("fake_id").SetMethod("mock_methodEmptyArgs").SetParams([]int{1, 2, 3}),
+			expectedResponse: NewResponse("fake_id").SetError(InvalidParamsError(parser.ErrInvalidArgsCount)),
+		},
+		{
+			name:             "call MethodEmptyArgs with empty params",
+			success:          true,
+			req:              client.NewRequest().SetID("fake_id").SetMethod("mock_methodEmptyArgs").SetParams([]int{}),
+			expectedResponse: NewResponse("fake_id").SetResult("foo"),
+		},
 		{
 			name:             "call MethodEmptyArgs with int identifier",
 			success:          true,
@@ -204,7 +320,7 @@ func TestJsonRPC2_ServeHTTP_Single(t *testing.T) {
 			name:             "call unknown method",
 			success:          false,
 			req:              client.NewRequest().SetID("fake_id").SetMethod("unknown"),
-			expectedResponse: NewResponse("fake_id").SetError(MethodNotFoundError(dispatcher.ErrNonExistentService)),
+			expectedResponse: NewResponse("fake_id").SetError(MethodNotFoundError(registry.ErrNonExistentService)),
 		},
 		{
 			name:             "call with empty body",
@@ -257,7 +373,8 @@ func TestJsonRPC2_ServeHTTP_Single(t *testing.T) {
 }
 
 func TestJsonRPC2_ServeHTTP_Batch(t *testing.T) {
-	s := New(context.TODO())
+	// Sleeping calls must overlap whatever the number of CPUs
+	s := New(context.TODO()).SetBatchWorkers(UnboundedBatchWorkers)
 	err := s.Register("mock", &mockService{})
 	assert.Equal(t, nil, err)
 
@@ -288,7 +405,7 @@ func TestJsonRPC2_ServeHTTP_Batch(t *testing.T) {
 				client.NewRequest().SetID("fake_id").SetMethod("unknown"),
 			},
 			expectedResponses: []*Response{
-				NewResponse("fake_id").SetError(MethodNotFoundError(dispatcher.ErrNonExistentService)),
+				NewResponse("fake_id").SetError(MethodNotFoundError(registry.ErrNonExistentService)),
 				NewResponse("fake_id").SetResult("foo"),
 			},
 		},
@@ -317,7 +434,7 @@ func TestJsonRPC2_ServeHTTP_Batch(t *testing.T) {
 				client.NewRequest().SetID("sleep_fast").SetMethod("mock_methodWithSleep").SetParams([]int64{1}),
 			},
 			expectedResponses: []*Response{
-				NewResponse("fake_id").SetError(MethodNotFoundError(dispatcher.ErrNonExistentService)),
+				NewResponse("fake_id").SetError(MethodNotFoundError(registry.ErrNonExistentService)),
 				NewResponse("sleep_fast").SetResult("slept well"),
 				NewResponse("sleep_medium").SetResult("slept well"),
 				NewResponse("sleep_long").SetResult("slept well"),
@@ -400,6 +517,20 @@ func TestJsonRPC2_ServeHTTP_Batch(t *testing.T) {
 	}
 }
 
+func TestJsonRPC2_ServeHTTP_NullResult(t *testing.T) {
+	s := New(context.TODO())
+	err := s.Register("mock", &mockService{})
+	assert.Equal(t, nil, err)
+
+	body := []byte(`{"jsonrpc": "2.0", "method": "mock_methodErrorOnly", "params": [false], "id": 1}`)
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	s.ServeHTTP(w, req)
+
+	assert.Equal(t, `{"jsonrpc":"2.0","result":null,"id":1}`, w.Body.String())
+}
+
 type officialExample struct{}
 type officialNotificationExample struct{}
 type officialGetExample struct{}
@@ -593,6 +724,153 @@ func TestJsonRPC2_ServeHTTP_Official_Example(t *testing.T) {
 	}
 }
 
+func TestJsonRPC2_ServeHTTP_Framing(t *testing.T) {
+	s := New(context.TODO())
+	err := s.Register("", &officialExample{})
+	assert.Equal(t, nil, err)
+
+	testCases := []struct {
+		name             string
+		req              []byte
+		expectedResponse string
+	}{
+		{
+			name:             "Empty body",
+			req:              []byte(``),
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error","data":"empty request body"},"id":null}`,
+		},
+		{
+			name:             "Whitespace body",
+			req:              []byte("\n \t"),
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error","data":"empty request body"},"id":null}`,
+		},
+		{
+			name:             "Single call with trailing newline",
+			req:              []byte("{\"jsonrpc\": \"2.0\", \"method\": \"sum\", \"params\": [1, 2], \"id\": 1}\n"),
+			expectedResponse: `{"jsonrpc":"2.0","result":3,"id":1}`,
+		},
+		{
+			name:             "Batch with leading and trailing whitespaces",
+			req:              []byte("\r\n  [{\"jsonrpc\": \"2.0\", \"method\": \"sum\", \"params\": [1, 2], \"id\": 1}]\n"),
+			expectedResponse: `[{"jsonrpc":"2.0","result":3,"id":1}]`,
+		},
+		{
+			name:             "Invalid JSON object",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "sum"`),
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error","data":"http request invalid JSON"},"id":null}`,
+		},
+		{
+			name:             "Valid JSON that is not an object",
+			req:              []byte(`"foo"`),
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"http request invalid body"},"id":null}`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.req))
+			w := httptest.NewRecorder()
+
+			s.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedResponse, w.Body.String())
+		})
+	}
+}
+
+func FuzzJsonRPC2_ServeHTTP(f *testing.F) {
+	s := New(context.TODO())
+	if err := s.Register("", &officialExample{}); err != nil {
+		f.Fatal(err)
+	}
+
+	for _, seed := range []string{
+		``,
+		" \n",
+		`{`,
+		`[`,
+		`]`,
+		`[]`,
+		`[1,2]`,
+		`null`,
+		`"foo"`,
+		`{"jsonrpc": "2.0", "method": "sum", "params": [1, 2], "id": 1}`,
+		`{"jsonrpc": "2.0", "method": "sum", "params": [1, 2]}`,
+		`{"jsonrpc": "2.0", "method": "positionalSubtract", "params": {"a": 1}, "id": "1"}`,
+		`{"jsonrpc": "2.0", "method": 1, "params": "bar"}`,
+		"\n[{\"jsonrpc\": \"2.0\", \"method\": \"sum\", \"params\": [1], \"id\": 1}, {\"foo\": \"boo\"}]\n",
+	} {
+		f.Add([]byte(seed))
+	}
+
+	// checkResponse verifies that res is a valid JSON-RPC 2.0 response object
+	checkResponse := func(t *testing.T, res interface{}) {
+		t.Helper()
+
+		obj, ok := res.(map[string]interface{})
+		if !assert.True(t, ok, "response must be an object") {
+			return
+		}
+
+		assert.Equal(t, "2.0", obj["jsonrpc"])
+		assert.Contains(t, obj, "id")
+
+		_, hasResult := obj["result"]
+		_, hasError := obj["error"]
+		assert.True(t, hasResult != hasError, "response must contain either result or error")
+	}
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+
+		s.ServeHTTP(w, req)
+
+		if w.Body.Len() == 0 {
+			return
+		}
+
+		var res interface{}
+		if !assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res), "response must be valid JSON") {
+			return
+		}
+
+		switch r := res.(type) {
+		case nil:
+		case []interface{}:
+			for _, e := range r {
+				checkResponse(t, e)
+			}
+		default:
+			checkResponse(t, r)
+		}
+	})
+}
+
+func benchmarkServeHTTP(b *testing.B, body []byte) {
+	b.Helper()
+
+	s := New(context.TODO())
+	if err := s.Register("mock", &mockService{}); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		s.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
+
+func BenchmarkJsonRPC2_ServeHTTP_Single(b *testing.B) {
+	benchmarkServeHTTP(b, []byte(`{"jsonrpc": "2.0", "method": "mock_methodWithComplexArgs", "params": [["foo", "bar"], 25, false, {"Id": 1, "Field1": true, "Field2": "baz"}], "id": 1}`))
+}
+
+func BenchmarkJsonRPC2_ServeHTTP_Batch(b *testing.B) {
+	benchmarkServeHTTP(b, []byte(`[{"jsonrpc": "2.0", "method": "mock_methodWithArgs", "params": ["foo", 1], "id": 1},{"jsonrpc": "2.0", "method": "mock_methodWithArgs", "params": ["bar", 2], "id": 2},{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": ["baz"], "id": 3}]`))
+}
+
 func TestJsonRPC2_Run(t *testing.T) {
 	//	ctx, cancel := context.WithCancel(context.TODO())
 	//
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrReservedMethodName = errors.New("method names beginning with rpc. are reserved")
+
+// reservedPrefix is the prefix of methods reserved for rpc-internal methods
+// and extensions
+const reservedPrefix = "rpc."
+
+// SetStrict enable the strict conformance mode, every MUST of the JSON RPC 2.0
+// specification is then enforced :
+//   - requests with unknown members or scalar params are rejected
+//   - requests with a null id are not notifications
+//   - methods beginning with rpc. can not be registered nor routed
+//
+// It must be set before registering services and handlers.
+func (s *JsonRPC2) SetStrict(strict bool) *JsonRPC2 {
+	s.strict = strict
+	return s
+}
+
+// checkReserved return ErrReservedMethodName in strict mode if name is
+// reserved by the specification
+func (s *JsonRPC2) checkReserved(name string) error {
+	if s.strict && strings.HasPrefix(name, reservedPrefix) {
+		return ErrReservedMethodName
+	}
+	return nil
+}
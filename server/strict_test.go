@@ -0,0 +1,79 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TomChv/jsonrpc2/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJsonRPC2_Strict_Register(t *testing.T) {
+	noop := HandlerFunc(func(ctx context.Context, req *common.Request) (interface{}, *common.RpcError) {
+		return nil, nil
+	})
+
+	s := New(context.TODO())
+	assert.Nil(t, s.Register("rpc.mock", &mockService{}))
+	assert.Nil(t, s.RegisterHandler("rpc.discover", noop))
+	assert.Nil(t, s.RegisterRoute("rpc.*", noop))
+
+	s = New(context.TODO()).SetStrict(true)
+	assert.ErrorIs(t, s.Register("rpc.mock", &mockService{}), ErrReservedMethodName)
+	assert.ErrorIs(t, s.RegisterHandler("rpc.discover", noop), ErrReservedMethodName)
+	assert.ErrorIs(t, s.RegisterRoute("rpc.*", noop), ErrReservedMethodName)
+}
+
+func TestJsonRPC2_ServeHTTP_Strict(t *testing.T) {
+	s := New(context.TODO()).SetStrict(true)
+	assert.Nil(t, s.Register("mock", &mockService{}))
+	assert.Nil(t, s.SetFallback(HandlerFunc(func(ctx context.Context, req *common.Request) (interface{}, *common.RpcError) {
+		return req.Method, nil
+	})))
+
+	testCases := []struct {
+		name             string
+		req              []byte
+		expectedResponse string
+	}{
+		{
+			name:             "Null identifier",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": ["foo"], "id": null}`),
+			expectedResponse: `{"jsonrpc":"2.0","result":"foo","id":null}`,
+		},
+		{
+			name:             "Unknown member",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": ["foo"], "id": 1, "foo": "bar"}`),
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"unknown request member: foo"},"id":1}`,
+		},
+		{
+			name:             "Scalar params",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "mock_methodWithArgString", "params": "foo", "id": 1}`),
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"params must be an array or an object"},"id":1}`,
+		},
+		{
+			name:             "Reserved method is not routed",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "rpc.foo", "id": 1}`),
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found","data":"service is not registered"},"id":1}`,
+		},
+		{
+			name:             "Notification only batch",
+			req:              []byte(`[{"jsonrpc": "2.0", "method": "mock_methodEmptyArgs"}, {"jsonrpc": "2.0", "method": "mock_methodEmptyArgs"}]`),
+			expectedResponse: ``,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.req))
+			w := httptest.NewRecorder()
+
+			s.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedResponse, w.Body.String())
+		})
+	}
+}
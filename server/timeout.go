@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"path"
+	"time"
+
+	"github.com/TomChv/jsonrpc2/common"
+)
+
+// methodTimeout binds a glob pattern to a timeout
+type methodTimeout struct {
+	pattern string
+	timeout time.Duration
+}
+
+// SetTimeout set the time a call may run before its context is cancelled
+// and a Timeout error is returned.
+// A zero timeout disables it, this is the default.
+func (s *JsonRPC2) SetTimeout(timeout time.Duration) *JsonRPC2 {
+	s.timeout = timeout
+	return s
+}
+
+// SetMethodTimeout set the timeout of the methods matching pattern, it
+// overrides the server timeout.
+// Pattern uses the path.Match syntax, if several patterns match, the first
+// one is used.
+func (s *JsonRPC2) SetMethodTimeout(pattern string, timeout time.Duration) error {
+	if pattern == "" {
+		return ErrEmptyMethodName
+	}
+
+	if _, err := path.Match(pattern, ""); err != nil {
+		return ErrInvalidPattern
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.methodTimeouts = append(s.methodTimeouts, methodTimeout{pattern: pattern, timeout: timeout})
+	return nil
+}
+
+// callTimeout return the timeout of c.
+// Patterns match the name of the called procedure as registered, so the
+// casing of the method does not escape them. The timeout sent by the
+// client in HeaderTimeout is used if it is shorter.
+func (s *JsonRPC2) callTimeout(ctx context.Context, c *call) time.Duration {
+	timeout := s.timeout
+
+	s.l.RLock()
+	for _, t := range s.methodTimeouts {
+		// Pattern is validated when the timeout is set
+		if ok, _ := path.Match(t.pattern, c.name); ok {
+			timeout = t.timeout
+			break
+		}
+	}
+	s.l.RUnlock()
+
+	if value := MetadataFromContext(ctx).Get(common.HeaderTimeout); value != "" {
+		if client, err := common.ParseTimeout(value); err == nil && (timeout <= 0 || client < timeout) {
+			timeout = client
+		}
+	}
+
+	return timeout
+}
+
+// withTimeout run call until ctx is done, it returns a Timeout error if
+// the deadline of ctx passes first, or a Canceled error if ctx is
+// cancelled.
+// The call keeps running in background until it returns, its response
+// metadata are then dropped.
+func withTimeout(ctx context.Context, req *Request, call func(ctx context.Context) *Response) *Response {
+	md := Metadata{}
+	done := make(chan *Response, 1)
+	go func() {
+		done <- call(context.WithValue(ctx, responseMetadataKey, md))
+	}()
+
+	select {
+	case res := <-done:
+		ResponseMetadata(ctx).merge(md)
+		return res
+	case <-ctx.Done():
+		return NewResponse(req.ID).SetError(contextError(ctx.Err()))
+	}
+}
+
+// contextError convert the error of a done context into a RPC error
+func contextError(err error) *RpcError {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return TimeoutError(err)
+	}
+	return CanceledError(err)
+}
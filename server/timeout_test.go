@@ -0,0 +1,163 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TomChv/jsonrpc2/common"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockSlowService struct {
+	cancelled chan struct{}
+}
+
+func (ms *mockSlowService) Sleep(ctx context.Context, delay int) (string, error) {
+	ResponseMetadata(ctx).Set("X-Slept", "true")
+
+	select {
+	case <-time.After(time.Duration(delay) * time.Millisecond):
+		return "done", nil
+	case <-ctx.Done():
+		ms.cancelled <- struct{}{}
+		return "", ctx.Err()
+	}
+}
+
+func (ms *mockSlowService) Block(delay int) string {
+	time.Sleep(time.Duration(delay) * time.Millisecond)
+	return "done"
+}
+
+func (ms *mockSlowService) Crash() string {
+	panic("boom")
+}
+
+func TestJsonRPC2_SetMethodTimeout(t *testing.T) {
+	s := New(context.TODO())
+
+	assert.Nil(t, s.SetMethodTimeout("slow_*", time.Second))
+	assert.Equal(t, ErrEmptyMethodName, s.SetMethodTimeout("", time.Second))
+	assert.Equal(t, ErrInvalidPattern, s.SetMethodTimeout("slow_[", time.Second))
+}
+
+func TestJsonRPC2_callTimeout(t *testing.T) {
+	s := New(context.TODO()).SetTimeout(time.Second)
+	assert.Nil(t, s.Register("slow", &mockSlowService{}))
+	assert.Nil(t, s.SetMethodTimeout("slow_block", 2*time.Second))
+	assert.Nil(t, s.SetMethodTimeout("slow_*", 100*time.Millisecond))
+
+	withClientTimeout := func(value string) context.Context {
+		md := Metadata{}
+		md.Set(common.HeaderTimeout, value)
+		return WithMetadata(context.TODO(), md)
+	}
+
+	callTimeout := func(s *JsonRPC2, ctx context.Context, method string) time.Duration {
+		return s.callTimeout(ctx, s.resolve(&Request{Method: method}))
+	}
+
+	assert.Equal(t, time.Second, callTimeout(s, context.TODO(), "mock_methodEmptyArgs"))
+	assert.Equal(t, 2*time.Second, callTimeout(s, context.TODO(), "slow_block"))
+	assert.Equal(t, 2*time.Second, callTimeout(s, context.TODO(), "slow_Block"))
+	assert.Equal(t, 100*time.Millisecond, callTimeout(s, context.TODO(), "slow_sleep"))
+	assert.Equal(t, 50*time.Millisecond, callTimeout(s, withClientTimeout("50"), "slow_sleep"))
+	assert.Equal(t, 100*time.Millisecond, callTimeout(s, withClientTimeout("500"), "slow_sleep"))
+	assert.Equal(t, 100*time.Millisecond, callTimeout(s, withClientTimeout("foo"), "slow_sleep"))
+	assert.Equal(t, 500*time.Millisecond, callTimeout(New(context.TODO()), withClientTimeout("500"), "slow_sleep"))
+}
+
+func TestJsonRPC2_ServeHTTP_Timeout(t *testing.T) {
+	service := &mockSlowService{cancelled: make(chan struct{}, 1)}
+	s := New(context.TODO()).SetTimeout(50 * time.Millisecond).SetHTTPOptions(HTTPOptions{StatusCodes: true})
+	assert.Nil(t, s.Register("slow", service))
+
+	testCases := []struct {
+		name             string
+		req              []byte
+		clientTimeout    string
+		expectedStatus   int
+		expectedHeader   string
+		expectedResponse string
+	}{
+		{
+			name:             "Within timeout",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "slow_sleep", "params": [0], "id": 1}`),
+			expectedStatus:   http.StatusOK,
+			expectedHeader:   "true",
+			expectedResponse: `{"jsonrpc":"2.0","result":"done","id":1}`,
+		},
+		{
+			name:             "Context is cancelled",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "slow_sleep", "params": [1000], "id": 1}`),
+			expectedStatus:   http.StatusGatewayTimeout,
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32004,"message":"Timeout","data":"context deadline exceeded"},"id":1}`,
+		},
+		{
+			name:             "Procedure ignoring its context",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "slow_block", "params": [1000], "id": 1}`),
+			expectedStatus:   http.StatusGatewayTimeout,
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32004,"message":"Timeout","data":"context deadline exceeded"},"id":1}`,
+		},
+		{
+			name:             "Client timeout",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "slow_block", "params": [30], "id": 1}`),
+			clientTimeout:    "1",
+			expectedStatus:   http.StatusGatewayTimeout,
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32004,"message":"Timeout","data":"context deadline exceeded"},"id":1}`,
+		},
+		{
+			name:             "Procedure panicking",
+			req:              []byte(`{"jsonrpc": "2.0", "method": "slow_crash", "id": 1}`),
+			expectedStatus:   http.StatusInternalServerError,
+			expectedResponse: `{"jsonrpc":"2.0","error":{"code":-32603,"message":"Internal error","data":"procedure panicked"},"id":1}`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.req))
+			if tt.clientTimeout != "" {
+				req.Header.Set(common.HeaderTimeout, tt.clientTimeout)
+			}
+			w := httptest.NewRecorder()
+
+			start := time.Now()
+			s.ServeHTTP(w, req)
+
+			assert.Less(t, time.Since(start), 500*time.Millisecond)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedHeader, w.Header().Get("X-Slept"))
+			assert.Equal(t, tt.expectedResponse, w.Body.String())
+		})
+	}
+
+	select {
+	case <-service.cancelled:
+	case <-time.After(time.Second):
+		t.Error("procedure context was not cancelled")
+	}
+}
+
+func TestJsonRPC2_ServeHTTP_Canceled(t *testing.T) {
+	service := &mockSlowService{cancelled: make(chan struct{}, 1)}
+	s := New(context.TODO()).SetTimeout(time.Second)
+	assert.Nil(t, s.Register("slow", service))
+
+	// The client gives up on its request
+	ctx, cancel := context.WithCancel(context.TODO())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	body := []byte(`{"jsonrpc": "2.0", "method": "slow_sleep", "params": [1000], "id": 1}`)
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body)).WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	s.ServeHTTP(w, req)
+
+	assert.Equal(t, `{"jsonrpc":"2.0","error":{"code":-32006,"message":"Canceled","data":"context canceled"},"id":1}`, w.Body.String())
+	<-service.cancelled
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/TomChv/jsonrpc2/common"
+	"github.com/TomChv/jsonrpc2/trace"
+)
+
+// SetTracer open spans for each HTTP request, batch and call with tracer.
+//
+// The trace context of incoming traceparent headers is propagated to the
+// handlers context whether a tracer is set or not.
+func (s *JsonRPC2) SetTracer(tracer trace.Tracer) *JsonRPC2 {
+	s.tracer = tracer
+	return s
+}
+
+// startSpan open a span if the server has a tracer
+func (s *JsonRPC2) startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	if s.tracer == nil {
+		return ctx, trace.NoopSpan{}
+	}
+	return s.tracer.Start(ctx, name)
+}
+
+// endCallSpan tag a call span with its request and response then end it.
+// Params and result are only added with payload logging, redacted, see
+// SetPayloadLogging.
+func (s *JsonRPC2) endCallSpan(span trace.Span, c *call, res *Response) {
+	if c != nil {
+		span.SetAttribute(trace.AttributeMethod, c.Method)
+		if c.ID != nil && c.ID != common.NullID {
+			span.SetAttribute(trace.AttributeID, fmt.Sprint(c.ID))
+		}
+	}
+
+	if s.payloadLog {
+		params, result := s.redactCall(c, res)
+		if params != "" {
+			span.SetAttribute(trace.AttributeParams, params)
+		}
+		if result != "" {
+			span.SetAttribute(trace.AttributeResult, result)
+		}
+	}
+
+	if res != nil && res.Error != nil {
+		span.SetAttribute(trace.AttributeErrorCode, res.Error.Code)
+	}
+
+	span.End()
+}
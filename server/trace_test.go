@@ -0,0 +1,86 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TomChv/jsonrpc2/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockTraceService struct{}
+
+func (ms *mockTraceService) Traceparent(ctx context.Context) string {
+	sc, _ := trace.SpanContextFromContext(ctx)
+	return sc.Traceparent()
+}
+
+func TestJsonRPC2_ServeHTTP_Tracer(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	recorder := trace.NewRecorder()
+	s := New(context.TODO()).SetTracer(recorder)
+	assert.Nil(t, s.Register("trace", &mockTraceService{}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`[
+		{"jsonrpc": "2.0", "method": "trace_traceparent", "id": 1},
+		{"jsonrpc": "2.0", "method": "trace_unknown", "id": "2"}
+	]`)))
+	req.Header.Set(trace.Header, traceparent)
+	s.SetBatchMode(BatchOrdered).ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Spans()
+	assert.Len(t, spans, 4)
+
+	byName := map[string][]*trace.RecordedSpan{}
+	for _, span := range spans {
+		byName[span.Name] = append(byName[span.Name], span)
+	}
+
+	request := byName["jsonrpc.request"][0]
+	assert.Equal(t, traceparent, request.Parent.Traceparent())
+	assert.Equal(t, "/", request.Attributes[trace.AttributeHTTPPath])
+
+	batch := byName["jsonrpc.batch"][0]
+	assert.Equal(t, request.SpanContext, batch.Parent)
+	assert.Equal(t, 2, batch.Attributes[trace.AttributeBatchSize])
+
+	calls := byName["jsonrpc.call"]
+	assert.Len(t, calls, 2)
+	for _, call := range calls {
+		assert.Equal(t, batch.SpanContext, call.Parent)
+		assert.Equal(t, request.Parent.TraceID, call.SpanContext.TraceID)
+	}
+
+	attributes := map[interface{}]map[string]interface{}{}
+	for _, call := range calls {
+		attributes[call.Attributes[trace.AttributeID]] = call.Attributes
+	}
+	assert.Equal(t, map[string]interface{}{
+		trace.AttributeMethod: "trace_traceparent",
+		trace.AttributeID:     "1",
+	}, attributes["1"])
+	assert.Equal(t, map[string]interface{}{
+		trace.AttributeMethod:    "trace_unknown",
+		trace.AttributeID:        "2",
+		trace.AttributeErrorCode: int64(-32601),
+	}, attributes["2"])
+}
+
+func TestJsonRPC2_ServeHTTP_TraceContext(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	// Without tracer, the incoming trace context is given to handlers
+	s := New(context.TODO())
+	assert.Nil(t, s.Register("trace", &mockTraceService{}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{"jsonrpc": "2.0", "method": "trace_traceparent", "id": 1}`)))
+	req.Header.Set(trace.Header, traceparent)
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+
+	assert.Equal(t, `{"jsonrpc":"2.0","result":"`+traceparent+`","id":1}`, w.Body.String())
+}
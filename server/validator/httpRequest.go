@@ -2,22 +2,54 @@ package validator
 
 import (
 	"errors"
+	"mime"
 	"net/http"
 )
 
 var (
 	ErrInvalidHTTPMethod  = errors.New("http method should be POST")
-	ErrInvalidPathRequest = errors.New("http request should target /")
+	ErrInvalidPathRequest = errors.New("http request should target the server path")
+	ErrInvalidContentType = errors.New("content type should be application/json")
 )
 
+// ContentTypeJSON is the media type of JSON RPC 2.0 requests and responses
+const ContentTypeJSON = "application/json"
+
+// HTTPRequest verify that r is a POST request that targets /
 func HTTPRequest(r *http.Request) error {
+	if err := HTTPMethod(r); err != nil {
+		return err
+	}
+
+	return HTTPPath(r, "/")
+}
+
+// HTTPMethod verify that r is a POST request
+func HTTPMethod(r *http.Request) error {
 	if r.Method != http.MethodPost {
 		return ErrInvalidHTTPMethod
 	}
 
-	if r.URL.Path != "/" {
+	return nil
+}
+
+// HTTPPath verify that r targets path.
+// An empty path accepts any request.
+func HTTPPath(r *http.Request, path string) error {
+	if path != "" && r.URL.Path != path {
 		return ErrInvalidPathRequest
 	}
 
 	return nil
 }
+
+// ContentType verify that the request body is sent as application/json.
+// Media type parameters such as charset are allowed.
+func ContentType(r *http.Request) error {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != ContentTypeJSON {
+		return ErrInvalidContentType
+	}
+
+	return nil
+}
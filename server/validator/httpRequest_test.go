@@ -44,3 +44,90 @@ func TestHTTPRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestContentType(t *testing.T) {
+	testCases := []struct {
+		name          string
+		contentType   string
+		expectedError error
+	}{
+		{
+			name:          "Missing content type",
+			contentType:   "",
+			expectedError: ErrInvalidContentType,
+		},
+		{
+			name:          "Invalid content type",
+			contentType:   "text/plain",
+			expectedError: ErrInvalidContentType,
+		},
+		{
+			name:          "Malformed content type",
+			contentType:   "application/json; charset",
+			expectedError: ErrInvalidContentType,
+		},
+		{
+			name:          "JSON content type",
+			contentType:   "application/json",
+			expectedError: nil,
+		},
+		{
+			name:          "JSON content type with charset",
+			contentType:   "application/json; charset=utf-8",
+			expectedError: nil,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", bytes.NewBuffer([]byte(`{"jsonrpc": "2.0", "id": 0, "method": "test"}`)))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			assert.Equal(t, tt.expectedError, ContentType(req))
+		})
+	}
+}
+
+func TestHTTPPath(t *testing.T) {
+	testCases := []struct {
+		name          string
+		target        string
+		path          string
+		expectedError error
+	}{
+		{
+			name:          "Root path",
+			target:        "/",
+			path:          "/",
+			expectedError: nil,
+		},
+		{
+			name:          "Mounted path",
+			target:        "/rpc",
+			path:          "/rpc",
+			expectedError: nil,
+		},
+		{
+			name:          "Invalid path",
+			target:        "/rpc/foo",
+			path:          "/rpc",
+			expectedError: ErrInvalidPathRequest,
+		},
+		{
+			name:          "Any path",
+			target:        "/rpc/foo",
+			path:          "",
+			expectedError: nil,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tt.target, bytes.NewBuffer([]byte(`{"jsonrpc": "2.0", "id": 0, "method": "test"}`)))
+
+			assert.Equal(t, tt.expectedError, HTTPPath(req, tt.path))
+		})
+	}
+}
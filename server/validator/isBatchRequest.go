@@ -8,16 +8,14 @@ import (
 )
 
 var (
+	ErrEmptyBody             = errors.New("empty request body")
 	ErrMissingClosingBracket = errors.New("invalid batch request : missing closing bracket")
 	ErrMissingOpeningBracket = errors.New("invalid batch request : missing opening bracket")
 )
 
 // IsBatchRequest return true if the request is wrapped with square brackets.
 func IsBatchRequest(r *http.Request) (bool, error) {
-	buf, _ := ioutil.ReadAll(r.Body)
-
-	body := ioutil.NopCloser(bytes.NewReader(buf))
-	data, err := ioutil.ReadAll(body)
+	buf, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		return false, err
 	}
@@ -25,12 +23,28 @@ func IsBatchRequest(r *http.Request) (bool, error) {
 	// Reset body
 	r.Body = ioutil.NopCloser(bytes.NewReader(buf))
 
+	return IsBatch(buf)
+}
+
+// IsBatch return true if the first JSON token of data is an opening square
+// bracket. Leading and trailing whitespaces are ignored.
+//
+// It returns an error if data is empty or if brackets are obviously
+// unbalanced, any other malformation is left to the JSON decoder.
+func IsBatch(data []byte) (bool, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return false, ErrEmptyBody
+	}
+
+	first, last := data[0], data[len(data)-1]
 	switch {
-	case data[0] == '[' && data[len(data)-1] == ']':
+	case first == '[' && last == ']':
 		return true, nil
-	case data[0] == '[':
+	case first == '[':
 		return false, ErrMissingClosingBracket
-	case data[len(data)-1] == ']':
+	// A valid JSON text ending with a bracket must be an array
+	case last == ']':
 		return false, ErrMissingOpeningBracket
 	default:
 		return false, nil
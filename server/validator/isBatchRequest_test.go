@@ -2,6 +2,7 @@ package validator
 
 import (
 	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -56,3 +57,77 @@ func TestIsBatchRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestIsBatch(t *testing.T) {
+	testCases := []struct {
+		name           string
+		data           []byte
+		expectedResult bool
+		expectedError  error
+	}{
+		{
+			name:           "Empty body",
+			data:           []byte(``),
+			expectedResult: false,
+			expectedError:  ErrEmptyBody,
+		},
+		{
+			name:           "Whitespace body",
+			data:           []byte(" \n\t\r "),
+			expectedResult: false,
+			expectedError:  ErrEmptyBody,
+		},
+		{
+			name:           "Single call with whitespaces",
+			data:           []byte("\n  {\"jsonrpc\": \"2.0\", \"method\": \"test\"}\n"),
+			expectedResult: false,
+			expectedError:  nil,
+		},
+		{
+			name:           "Batch call with whitespaces",
+			data:           []byte("\r\n [{\"jsonrpc\": \"2.0\", \"method\": \"test\"}]\n"),
+			expectedResult: true,
+			expectedError:  nil,
+		},
+		{
+			name:           "Missing closing square bracket with trailing newline",
+			data:           []byte("[{\"jsonrpc\": \"2.0\", \"method\": \"test\"}\n"),
+			expectedResult: false,
+			expectedError:  ErrMissingClosingBracket,
+		},
+		{
+			name:           "Scalar",
+			data:           []byte(`1`),
+			expectedResult: false,
+			expectedError:  nil,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			isBatch, err := IsBatch(tt.data)
+
+			assert.Equal(t, tt.expectedResult, isBatch)
+			assert.Equal(t, tt.expectedError, err)
+		})
+	}
+}
+
+func FuzzIsBatch(f *testing.F) {
+	for _, seed := range []string{``, ` `, `[]`, `{}`, `[`, `]`, ` [1] `, "\n{\"jsonrpc\": \"2.0\"}\n", `"]"`} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		isBatch, err := IsBatch(data)
+		if err != nil {
+			assert.False(t, isBatch)
+		}
+
+		// Valid JSON is never rejected and is a batch if it is an array
+		if json.Valid(data) {
+			assert.Nil(t, err)
+			assert.Equal(t, bytes.TrimSpace(data)[0] == '[', isBatch)
+		}
+	})
+}
@@ -14,7 +14,7 @@ var (
 )
 
 func JsonRPCRequest(req *common.Request) error {
-	if req.ID != nil {
+	if req.ID != nil && req.ID != common.NullID {
 		switch reflect.TypeOf(req.ID).String() {
 		case "string":
 			break
@@ -0,0 +1,50 @@
+package validator
+
+import (
+	"errors"
+)
+
+var (
+	ErrBodyTooLarge  = errors.New("request body is too large")
+	ErrBatchTooLarge = errors.New("batch contains too many requests")
+	ErrJSONTooDeep   = errors.New("JSON exceeds maximum nesting depth")
+)
+
+// JSONDepth return ErrJSONTooDeep if objects and arrays of data are nested
+// deeper than maxDepth.
+//
+// It only scans brackets outside of strings, data is not required to be valid
+// JSON.
+func JSONDepth(data []byte, maxDepth int) error {
+	depth := 0
+	inString := false
+	escaped := false
+
+	for _, c := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > maxDepth {
+				return ErrJSONTooDeep
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONDepth(t *testing.T) {
+	testCases := []struct {
+		name          string
+		data          []byte
+		max           int
+		expectedError error
+	}{
+		{
+			name:          "Flat object",
+			data:          []byte(`{"jsonrpc": "2.0", "method": "test"}`),
+			max:           1,
+			expectedError: nil,
+		},
+		{
+			name:          "Nested at limit",
+			data:          []byte(`[{"params": [1, 2]}]`),
+			max:           3,
+			expectedError: nil,
+		},
+		{
+			name:          "Nested above limit",
+			data:          []byte(`[{"params": [[1, 2]]}]`),
+			max:           3,
+			expectedError: ErrJSONTooDeep,
+		},
+		{
+			name:          "Brackets in strings are ignored",
+			data:          []byte(`{"params": "[[[{{{\"]]]"}`),
+			max:           1,
+			expectedError: nil,
+		},
+		{
+			name:          "Invalid JSON",
+			data:          []byte(`[[[[`),
+			max:           3,
+			expectedError: ErrJSONTooDeep,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedError, JSONDepth(tt.data, tt.max))
+		})
+	}
+}
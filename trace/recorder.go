@@ -0,0 +1,78 @@
+package trace
+
+import (
+	"context"
+	"sync"
+)
+
+// Recorder is a Tracer that keeps ended spans in memory, it is meant for
+// tests and debugging
+type Recorder struct {
+	spans []*RecordedSpan
+	l     sync.Mutex
+}
+
+// RecordedSpan is a span opened by a Recorder
+type RecordedSpan struct {
+	Name        string
+	SpanContext SpanContext
+	Parent      SpanContext
+	Attributes  map[string]interface{}
+
+	recorder *Recorder
+	ended    bool
+	l        sync.Mutex
+}
+
+// NewRecorder create an empty Recorder
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Start open a span
+func (r *Recorder) Start(ctx context.Context, name string) (context.Context, Span) {
+	parent, _ := SpanContextFromContext(ctx)
+
+	span := &RecordedSpan{
+		Name:        name,
+		SpanContext: NewSpanContext(parent),
+		Parent:      parent,
+		Attributes:  make(map[string]interface{}),
+		recorder:    r,
+	}
+
+	return ContextWithSpanContext(ctx, span.SpanContext), span
+}
+
+// Spans return the ended spans in the order they ended
+func (r *Recorder) Spans() []*RecordedSpan {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	return append([]*RecordedSpan(nil), r.spans...)
+}
+
+// SetAttribute set an attribute of the span
+func (s *RecordedSpan) SetAttribute(key string, value interface{}) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.Attributes[key] = value
+}
+
+// End record the span, calls after the first one do nothing
+func (s *RecordedSpan) End() {
+	s.l.Lock()
+	ended := s.ended
+	s.ended = true
+	s.l.Unlock()
+
+	if ended {
+		return
+	}
+
+	s.recorder.l.Lock()
+	defer s.recorder.l.Unlock()
+
+	s.recorder.spans = append(s.recorder.spans, s)
+}
@@ -0,0 +1,30 @@
+package trace
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	r := NewRecorder()
+
+	ctx, parent := r.Start(context.TODO(), "parent")
+	_, child := r.Start(ctx, "child")
+	child.SetAttribute("foo", "bar")
+	child.End()
+	parent.End()
+
+	// Spans are recorded once
+	child.End()
+
+	spans := r.Spans()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "child", spans[0].Name)
+	assert.Equal(t, "parent", spans[1].Name)
+	assert.Equal(t, map[string]interface{}{"foo": "bar"}, spans[0].Attributes)
+	assert.Equal(t, spans[1].SpanContext, spans[0].Parent)
+	assert.Equal(t, spans[1].SpanContext.TraceID, spans[0].SpanContext.TraceID)
+	assert.False(t, spans[1].Parent.IsValid())
+}
@@ -0,0 +1,155 @@
+// Package trace defines the tracing hooks of the server and client, and
+// propagates trace context in the W3C traceparent header.
+//
+// It has no dependency, tracing backends such as OpenTelemetry are plugged
+// by implementing Tracer.
+//
+// See https://www.w3.org/TR/trace-context/ for more information
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+var ErrInvalidTraceparent = errors.New("invalid traceparent")
+
+// Header is the header that carries the trace context
+const Header = "traceparent"
+
+// Attributes of spans
+const (
+	AttributeMethod    = "rpc.method"
+	AttributeID        = "rpc.id"
+	AttributeErrorCode = "rpc.error_code"
+	AttributeParams    = "rpc.params"
+	AttributeResult    = "rpc.result"
+	AttributeBatchSize = "rpc.batch_size"
+	AttributeHTTPPath  = "http.path"
+)
+
+// Tracer opens spans
+type Tracer interface {
+	// Start open a span as child of the span context held by ctx, if any.
+	// It returns a copy of ctx that holds the span context of the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a traced operation
+type Span interface {
+	SetAttribute(key string, value interface{})
+	End()
+}
+
+// NoopSpan is a Span that records nothing, it is used when no tracer is set
+type NoopSpan struct{}
+
+func (NoopSpan) SetAttribute(string, interface{}) {}
+func (NoopSpan) End()                             {}
+
+// SpanContext identifies a span across process boundaries
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+// IsValid return true if the trace and span identifiers are set
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Traceparent format sc as a traceparent header value
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + hex.EncodeToString(sc.TraceID[:]) + "-" + hex.EncodeToString(sc.SpanID[:]) + "-" + flags
+}
+
+// Parse a traceparent header value
+func Parse(traceparent string) (SpanContext, error) {
+	var sc SpanContext
+
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return sc, ErrInvalidTraceparent
+	}
+
+	var version, flags [1]byte
+	if _, err := hex.Decode(version[:], []byte(parts[0])); err != nil {
+		return sc, ErrInvalidTraceparent
+	}
+	if len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, ErrInvalidTraceparent
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, ErrInvalidTraceparent
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, ErrInvalidTraceparent
+	}
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return sc, ErrInvalidTraceparent
+	}
+
+	// Upper case hexadecimal is not allowed
+	if strings.ToLower(traceparent) != traceparent || !sc.IsValid() {
+		return sc, ErrInvalidTraceparent
+	}
+
+	sc.Sampled = flags[0]&1 == 1
+	return sc, nil
+}
+
+// NewSpanContext create the span context of a child of parent.
+// If parent is not valid, a new trace is started.
+func NewSpanContext(parent SpanContext) SpanContext {
+	sc := SpanContext{TraceID: parent.TraceID, Sampled: parent.Sampled}
+	if !parent.IsValid() {
+		_, _ = rand.Read(sc.TraceID[:])
+		sc.Sampled = true
+	}
+	_, _ = rand.Read(sc.SpanID[:])
+	return sc
+}
+
+type contextKey struct{}
+
+// ContextWithSpanContext return a copy of ctx that holds sc
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, contextKey{}, sc)
+}
+
+// SpanContextFromContext return the span context held by ctx
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(contextKey{}).(SpanContext)
+	return sc, ok && sc.IsValid()
+}
+
+// Extract return a copy of ctx that holds the span context of the
+// traceparent header, ctx is returned unchanged if there is none
+func Extract(ctx context.Context, h http.Header) context.Context {
+	value := h.Get(Header)
+	if value == "" {
+		return ctx
+	}
+
+	sc, err := Parse(value)
+	if err != nil {
+		return ctx
+	}
+	return ContextWithSpanContext(ctx, sc)
+}
+
+// Inject set the traceparent header from the span context held by ctx
+func Inject(ctx context.Context, h http.Header) {
+	if sc, ok := SpanContextFromContext(ctx); ok {
+		h.Set(Header, sc.Traceparent())
+	}
+}
@@ -0,0 +1,125 @@
+package trace
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name          string
+		traceparent   string
+		expected      string
+		sampled       bool
+		expectedError error
+	}{
+		{
+			name:        "Sampled",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			expected:    "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			sampled:     true,
+		},
+		{
+			name:        "Not sampled",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+			expected:    "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+		},
+		{
+			name:        "Future version",
+			traceparent: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-foo",
+			expected:    "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			sampled:     true,
+		},
+		{
+			name:          "Invalid version",
+			traceparent:   "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			expectedError: ErrInvalidTraceparent,
+		},
+		{
+			name:          "Extra fields in version 00",
+			traceparent:   "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-foo",
+			expectedError: ErrInvalidTraceparent,
+		},
+		{
+			name:          "Zero trace id",
+			traceparent:   "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+			expectedError: ErrInvalidTraceparent,
+		},
+		{
+			name:          "Zero span id",
+			traceparent:   "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+			expectedError: ErrInvalidTraceparent,
+		},
+		{
+			name:          "Upper case",
+			traceparent:   "00-4BF92F3577B34DA6A3CE929D0E0E4736-00F067AA0BA902B7-01",
+			expectedError: ErrInvalidTraceparent,
+		},
+		{
+			name:          "Short trace id",
+			traceparent:   "00-4bf92f3577b34da6-00f067aa0ba902b7-01",
+			expectedError: ErrInvalidTraceparent,
+		},
+		{
+			name:          "Not hexadecimal",
+			traceparent:   "00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01",
+			expectedError: ErrInvalidTraceparent,
+		},
+		{
+			name:          "Empty",
+			traceparent:   "",
+			expectedError: ErrInvalidTraceparent,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, err := Parse(tt.traceparent)
+
+			assert.Equal(t, tt.expectedError, err)
+			if err == nil {
+				assert.Equal(t, tt.expected, sc.Traceparent())
+				assert.Equal(t, tt.sampled, sc.Sampled)
+			}
+		})
+	}
+}
+
+func TestNewSpanContext(t *testing.T) {
+	root := NewSpanContext(SpanContext{})
+	assert.True(t, root.IsValid())
+	assert.True(t, root.Sampled)
+
+	child := NewSpanContext(root)
+	assert.True(t, child.IsValid())
+	assert.Equal(t, root.TraceID, child.TraceID)
+	assert.NotEqual(t, root.SpanID, child.SpanID)
+}
+
+func TestExtractInject(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	in := http.Header{}
+	in.Set(Header, traceparent)
+	ctx := Extract(context.TODO(), in)
+
+	sc, ok := SpanContextFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, traceparent, sc.Traceparent())
+
+	out := http.Header{}
+	Inject(ctx, out)
+	assert.Equal(t, traceparent, out.Get(Header))
+
+	// Invalid or missing headers are ignored
+	in.Set(Header, "foo")
+	_, ok = SpanContextFromContext(Extract(context.TODO(), in))
+	assert.False(t, ok)
+
+	out = http.Header{}
+	Inject(context.TODO(), out)
+	assert.Equal(t, "", out.Get(Header))
+}